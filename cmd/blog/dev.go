@@ -0,0 +1,264 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"hash/fnv"
+	"io/fs"
+	"log/slog"
+	"net/http"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/cesarFuhr/cesarfuhr.dev-app/internal/gen"
+)
+
+// devTemplates is where the generator templates live, relative to the
+// site config. Reading them from disk lets template changes be picked up
+// without restarting.
+const devTemplates = "internal/gen/templates"
+
+const devEventsPath = "/_dev/events"
+
+// reloadScript is added to every page, reloading it once the site is
+// rebuilt.
+const reloadScript = `<script>new EventSource("` + devEventsPath + `").addEventListener("reload", () => location.reload());</script>`
+
+// devSite serves the generated site from disk, rebuilding it whenever the
+// content, templates, static files, examples or the config change. It is
+// built with drafts into a folder of its own, the embedded site is left
+// for production builds.
+type devSite struct {
+	logger     *slog.Logger
+	configFile string
+	output     string
+	// tempOutput tells the output was created for this run only.
+	tempOutput bool
+	// cfg is only used by the watch goroutine, reloaded on every rebuild.
+	cfg gen.Config
+
+	mu      sync.Mutex
+	clients map[chan struct{}]struct{}
+	closed  bool
+}
+
+// newDevSite builds into output, a temporary folder when empty.
+func newDevSite(logger *slog.Logger, configFile, output string) (*devSite, error) {
+	d := &devSite{
+		logger:     logger,
+		configFile: configFile,
+		output:     output,
+		clients:    map[chan struct{}]struct{}{},
+	}
+
+	if d.output == "" {
+		dir, err := os.MkdirTemp("", "blog-dev-")
+		if err != nil {
+			return nil, err
+		}
+		d.output, d.tempOutput = dir, true
+	}
+
+	cfg, err := d.loadConfig()
+	if err != nil {
+		d.removeOutput()
+		return nil, err
+	}
+	d.cfg = cfg
+
+	return d, nil
+}
+
+// loadConfig reads the site config, with the dev settings on top.
+func (d *devSite) loadConfig() (gen.Config, error) {
+	cfg, err := gen.LoadConfig([]string{"-config", d.configFile, "-drafts", "-output", d.output})
+	if err != nil {
+		return cfg, err
+	}
+	if cfg.TemplateDir == "" {
+		cfg.TemplateDir = filepath.Join(filepath.Dir(d.configFile), devTemplates)
+	}
+	cfg.Warnf = func(format string, args ...any) {
+		d.logger.Warn(fmt.Sprintf(format, args...))
+	}
+	return cfg, nil
+}
+
+// removeOutput deletes the output folder if it was created for this run.
+func (d *devSite) removeOutput() {
+	if d.tempOutput {
+		os.RemoveAll(d.output)
+	}
+}
+
+// watch rebuilds the site on every change until ctx is done. There are
+// only a handful of files, polling them is cheap enough.
+func (d *devSite) watch(ctx context.Context) {
+	ticker := time.NewTicker(500 * time.Millisecond)
+	defer ticker.Stop()
+
+	var last uint64
+	for {
+		if sum := d.fingerprint(); sum != last {
+			last = sum
+			d.rebuild()
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// fingerprint summarizes the names, sizes and modification times of
+// every source of the site.
+func (d *devSite) fingerprint() uint64 {
+	h := fnv.New64a()
+	for _, dir := range []string{d.cfg.SourceDir, d.cfg.StaticDir, d.cfg.TemplateDir, d.cfg.ExamplesDir, d.configFile} {
+		if dir == "" {
+			continue
+		}
+		filepath.WalkDir(dir, func(path string, entry fs.DirEntry, err error) error {
+			if err != nil {
+				return nil
+			}
+			info, err := entry.Info()
+			if err != nil {
+				return nil
+			}
+			fmt.Fprintf(h, "%s %d %d\n", path, info.Size(), info.ModTime().UnixNano())
+			return nil
+		})
+	}
+	return h.Sum64()
+}
+
+func (d *devSite) rebuild() {
+	start := time.Now()
+
+	// The config file is watched too, changes to it apply right away.
+	cfg, err := d.loadConfig()
+	if err != nil {
+		d.logger.Error("site config reload failed", "err", err)
+		return
+	}
+	d.cfg = cfg
+
+	if err := gen.Build(d.cfg); err != nil {
+		d.logger.Error("site rebuild failed", "err", err)
+		return
+	}
+	d.logger.Info("site rebuilt", "duration", time.Since(start))
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	for client := range d.clients {
+		select {
+		case client <- struct{}{}:
+		default:
+			// A reload is already pending for this client.
+		}
+	}
+}
+
+// handler serves the site with the reload script added to its pages,
+// plus the events reloading them.
+func (d *devSite) handler() http.Handler {
+	files := http.FileServerFS(os.DirFS(d.output))
+
+	mux := http.NewServeMux()
+	mux.HandleFunc(devEventsPath, d.events)
+	mux.Handle("/", http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+		// Always serving the latest build.
+		rw.Header().Set("Cache-Control", "no-store")
+
+		name := strings.TrimPrefix(path.Clean(r.URL.Path), "/")
+		// Hidden files, like the generator manifest, are not part of the site.
+		if strings.HasPrefix(name, ".") || strings.Contains(name, "/.") {
+			http.NotFound(rw, r)
+			return
+		}
+		if name == "" || strings.HasSuffix(r.URL.Path, "/") {
+			name = path.Join(name, "index.html")
+		}
+		if path.Ext(name) != ".html" {
+			files.ServeHTTP(rw, r)
+			return
+		}
+
+		page, err := os.ReadFile(filepath.Join(d.output, filepath.FromSlash(name)))
+		if err != nil {
+			files.ServeHTTP(rw, r)
+			return
+		}
+		page = bytes.Replace(page, []byte("</body>"), []byte(reloadScript+"</body>"), 1)
+
+		rw.Header().Set("Content-Type", "text/html; charset=utf-8")
+		rw.Write(page)
+	}))
+
+	return mux
+}
+
+// events streams a reload event, as server sent events, after every
+// successful rebuild.
+func (d *devSite) events(rw http.ResponseWriter, r *http.Request) {
+	flusher, ok := rw.(http.Flusher)
+	if !ok {
+		http.Error(rw, "streaming not supported", http.StatusInternalServerError)
+		return
+	}
+
+	client := make(chan struct{}, 1)
+	d.mu.Lock()
+	if d.closed {
+		d.mu.Unlock()
+		http.Error(rw, "shutting down", http.StatusServiceUnavailable)
+		return
+	}
+	d.clients[client] = struct{}{}
+	d.mu.Unlock()
+
+	defer func() {
+		d.mu.Lock()
+		delete(d.clients, client)
+		d.mu.Unlock()
+	}()
+
+	rw.Header().Set("Content-Type", "text/event-stream")
+	rw.Header().Set("Cache-Control", "no-store")
+	fmt.Fprint(rw, ": connected\n\n")
+	flusher.Flush()
+
+	for {
+		select {
+		case <-r.Context().Done():
+			return
+		case _, ok := <-client:
+			if !ok {
+				return
+			}
+			fmt.Fprint(rw, "event: reload\ndata: reload\n\n")
+			flusher.Flush()
+		}
+	}
+}
+
+// close ends the event streams, they would hold the server shutdown.
+func (d *devSite) close() {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	d.closed = true
+	for client := range d.clients {
+		close(client)
+		delete(d.clients, client)
+	}
+}
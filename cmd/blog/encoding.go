@@ -0,0 +1,139 @@
+package main
+
+import (
+	"io"
+	"io/fs"
+	"mime"
+	"net/http"
+	"path"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// encodings the generator precompresses files with, by server preference.
+var encodings = []struct {
+	name string
+	ext  string
+}{
+	{name: "br", ext: ".br"},
+	{name: "gzip", ext: ".gz"},
+}
+
+// Types of the site files missing from the system mime tables.
+var contentTypes = map[string]string{
+	".rss":  "application/rss+xml; charset=utf-8",
+	".atom": "application/atom+xml; charset=utf-8",
+}
+
+// fileName is the name, in the site FS, of the file at urlPath. Paths to
+// index.html are left out, the file server redirects them to the
+// directory.
+func fileName(urlPath string) (string, bool) {
+	if strings.HasSuffix(urlPath, "/index.html") {
+		return "", false
+	}
+	name := strings.TrimPrefix(path.Clean(urlPath), "/")
+	if name == "" || strings.HasSuffix(urlPath, "/") {
+		name = path.Join(name, "index.html")
+	}
+	return name, true
+}
+
+// precompressed serves the compressed variant of a file, written by the
+// generator next to it, when the client accepts its encoding. Anything
+// else is left to next.
+func precompressed(fsys fs.FS, tags etags, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+		name, ok := fileName(r.URL.Path)
+		if !ok {
+			next.ServeHTTP(rw, r)
+			return
+		}
+
+		info, err := fs.Stat(fsys, name)
+		if err != nil || info.IsDir() {
+			next.ServeHTTP(rw, r)
+			return
+		}
+
+		var variants []string
+		for _, enc := range encodings {
+			if _, err := fs.Stat(fsys, name+enc.ext); err == nil {
+				variants = append(variants, enc.name)
+			}
+		}
+		if len(variants) == 0 {
+			next.ServeHTTP(rw, r)
+			return
+		}
+
+		// Caches must keep apart the responses for each encoding.
+		rw.Header().Add("Vary", "Accept-Encoding")
+
+		accepts := acceptedEncodings(r.Header.Get("Accept-Encoding"))
+		for _, enc := range encodings {
+			if !accepts(enc.name) || !slices.Contains(variants, enc.name) {
+				continue
+			}
+
+			f, err := fsys.Open(name + enc.ext)
+			if err != nil {
+				break
+			}
+			defer f.Close()
+			content, ok := f.(io.ReadSeeker)
+			if !ok {
+				break
+			}
+
+			ctype := mime.TypeByExtension(path.Ext(name))
+			if ctype == "" {
+				ctype = contentTypes[path.Ext(name)]
+			}
+			if ctype != "" {
+				// Sniffing the compressed bytes would get it wrong.
+				rw.Header().Set("Content-Type", ctype)
+			}
+			rw.Header().Set("Content-Encoding", enc.name)
+			// Each encoding is a different representation.
+			if tag, ok := tags[name+enc.ext]; ok {
+				rw.Header().Set("Etag", tag)
+			}
+			http.ServeContent(rw, r, name, info.ModTime(), content)
+			return
+		}
+
+		next.ServeHTTP(rw, r)
+	})
+}
+
+// acceptedEncodings parses an Accept-Encoding header, telling if an
+// encoding is accepted. The ones with q=0 are refused.
+func acceptedEncodings(header string) func(string) bool {
+	accepted := map[string]bool{}
+	for _, part := range strings.Split(header, ",") {
+		name, params, _ := strings.Cut(part, ";")
+		name = strings.ToLower(strings.TrimSpace(name))
+		if name == "" {
+			continue
+		}
+
+		q := 1.0
+		for _, param := range strings.Split(params, ";") {
+			if v, ok := strings.CutPrefix(strings.TrimSpace(param), "q="); ok {
+				if f, err := strconv.ParseFloat(v, 64); err == nil {
+					q = f
+				}
+			}
+		}
+		accepted[name] = q > 0
+	}
+
+	return func(name string) bool {
+		if ok, listed := accepted[name]; listed {
+			return ok
+		}
+		return accepted["*"]
+	}
+}
@@ -0,0 +1,193 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"testing/fstest"
+)
+
+func TestAcceptedEncodings(t *testing.T) {
+	tests := []struct {
+		header string
+		name   string
+		want   bool
+	}{
+		{header: "", name: "gzip", want: false},
+		{header: "gzip", name: "gzip", want: true},
+		{header: "gzip", name: "br", want: false},
+		{header: "GZIP, Br", name: "br", want: true},
+		{header: "gzip, deflate, br, zstd", name: "br", want: true},
+		{header: "br;q=0.5, gzip;q=1.0", name: "br", want: true},
+		{header: "br;q=0, gzip", name: "br", want: false},
+		{header: "br; q=0.0", name: "br", want: false},
+		{header: "br;q=invalid", name: "br", want: true},
+		{header: "*", name: "br", want: true},
+		{header: "*;q=0", name: "gzip", want: false},
+		{header: "br;q=0, *", name: "br", want: false},
+		{header: "br, *;q=0", name: "br", want: true},
+		{header: "br, *;q=0", name: "gzip", want: false},
+	}
+	for _, test := range tests {
+		if got := acceptedEncodings(test.header)(test.name); got != test.want {
+			t.Errorf("acceptedEncodings(%q)(%q) = %v, want %v", test.header, test.name, got, test.want)
+		}
+	}
+}
+
+// testSite is a generated site with precompressed variants, their content
+// tells them apart.
+func testSite() fstest.MapFS {
+	return fstest.MapFS{
+		"index.html":        {Data: []byte("<p>index</p>")},
+		"index.html.gz":     {Data: []byte("index gzip")},
+		"index.html.br":     {Data: []byte("index brotli")},
+		"blog/post.html":    {Data: []byte("<p>post</p>")},
+		"blog/post.html.gz": {Data: []byte("post gzip")},
+		"index.rss":         {Data: []byte("<rss></rss>")},
+		"index.rss.gz":      {Data: []byte("rss gzip")},
+		"images/gopher.png": {Data: []byte("png")},
+	}
+}
+
+func TestPrecompressed(t *testing.T) {
+	site := testSite()
+	tags, err := newETags(site)
+	if err != nil {
+		t.Fatal(err)
+	}
+	h := tags.handler(precompressed(site, tags, http.FileServerFS(site)))
+
+	tests := []struct {
+		name           string
+		path           string
+		acceptEncoding string
+		body           string
+		encoding       string
+		contentType    string
+		etag           string
+		vary           bool
+	}{
+		{
+			name:           "brotli preferred",
+			path:           "/",
+			acceptEncoding: "gzip, deflate, br",
+			body:           "index brotli",
+			encoding:       "br",
+			contentType:    "text/html; charset=utf-8",
+			etag:           tags["index.html.br"],
+			vary:           true,
+		},
+		{
+			name:           "variant requested by name",
+			path:           "/index.html.gz",
+			acceptEncoding: "gzip",
+			body:           "index gzip",
+			etag:           tags["index.html.gz"],
+		},
+		{
+			name:           "gzip only",
+			path:           "/",
+			acceptEncoding: "gzip",
+			body:           "index gzip",
+			encoding:       "gzip",
+			contentType:    "text/html; charset=utf-8",
+			etag:           tags["index.html.gz"],
+			vary:           true,
+		},
+		{
+			name:           "brotli refused",
+			path:           "/",
+			acceptEncoding: "br;q=0, *",
+			body:           "index gzip",
+			encoding:       "gzip",
+			contentType:    "text/html; charset=utf-8",
+			etag:           tags["index.html.gz"],
+			vary:           true,
+		},
+		{
+			name:        "no encoding accepted",
+			path:        "/",
+			body:        "<p>index</p>",
+			contentType: "text/html; charset=utf-8",
+			etag:        tags["index.html"],
+			vary:        true,
+		},
+		{
+			name:           "only variant not accepted",
+			path:           "/blog/post.html",
+			acceptEncoding: "br",
+			body:           "<p>post</p>",
+			contentType:    "text/html; charset=utf-8",
+			etag:           tags["blog/post.html"],
+			vary:           true,
+		},
+		{
+			name:           "feed",
+			path:           "/index.rss",
+			acceptEncoding: "gzip",
+			body:           "rss gzip",
+			encoding:       "gzip",
+			contentType:    "application/rss+xml",
+			etag:           tags["index.rss.gz"],
+			vary:           true,
+		},
+		{
+			name:           "no variants",
+			path:           "/images/gopher.png",
+			acceptEncoding: "gzip, br",
+			body:           "png",
+			contentType:    "image/png",
+			etag:           tags["images/gopher.png"],
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			r := httptest.NewRequest(http.MethodGet, test.path, nil)
+			if test.acceptEncoding != "" {
+				r.Header.Set("Accept-Encoding", test.acceptEncoding)
+			}
+			rw := httptest.NewRecorder()
+			h.ServeHTTP(rw, r)
+
+			if rw.Code != http.StatusOK {
+				t.Fatalf("got status %d, want 200", rw.Code)
+			}
+			if got := rw.Body.String(); got != test.body {
+				t.Errorf("got body %q, want %q", got, test.body)
+			}
+			if got := rw.Header().Get("Content-Encoding"); got != test.encoding {
+				t.Errorf("got Content-Encoding %q, want %q", got, test.encoding)
+			}
+			// The system mime tables may add a charset.
+			if got := rw.Header().Get("Content-Type"); !strings.HasPrefix(got, test.contentType) {
+				t.Errorf("got Content-Type %q, want %q", got, test.contentType)
+			}
+			if got := rw.Header().Get("Etag"); got == "" || got != test.etag {
+				t.Errorf("got ETag %q, want %q", got, test.etag)
+			}
+			if got := rw.Header().Get("Vary") == "Accept-Encoding"; got != test.vary {
+				t.Errorf("got Vary %q, want it set %v", rw.Header().Get("Vary"), test.vary)
+			}
+		})
+	}
+}
+
+func TestPrecompressedLeavesRedirects(t *testing.T) {
+	site := testSite()
+	tags, err := newETags(site)
+	if err != nil {
+		t.Fatal(err)
+	}
+	h := tags.handler(precompressed(site, tags, http.FileServerFS(site)))
+
+	r := httptest.NewRequest(http.MethodGet, "/index.html", nil)
+	r.Header.Set("Accept-Encoding", "gzip, br")
+	rw := httptest.NewRecorder()
+	h.ServeHTTP(rw, r)
+
+	if rw.Code != http.StatusMovedPermanently || rw.Header().Get("Location") != "./" {
+		t.Errorf("got status %d to %q, want a redirect to ./", rw.Code, rw.Header().Get("Location"))
+	}
+}
@@ -0,0 +1,98 @@
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"io"
+	"io/fs"
+	"net/http"
+	"time"
+)
+
+// etags are the strong entity tags of the embedded files, by name. The
+// files never change while the server runs, hashing them once is enough.
+type etags map[string]string
+
+func newETags(fsys fs.FS) (etags, error) {
+	tags := etags{}
+	err := fs.WalkDir(fsys, ".", func(name string, entry fs.DirEntry, err error) error {
+		if err != nil || entry.IsDir() {
+			return err
+		}
+		b, err := fs.ReadFile(fsys, name)
+		if err != nil {
+			return err
+		}
+		sum := sha256.Sum256(b)
+		tags[name] = `"` + hex.EncodeToString(sum[:16]) + `"`
+		return nil
+	})
+	return tags, err
+}
+
+// handler sets the ETag of the requested file, next then answers the
+// requests with a matching If-None-Match with a 304.
+func (e etags) handler(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+		if name, ok := fileName(r.URL.Path); ok {
+			if tag, ok := e[name]; ok {
+				rw.Header().Set("Etag", tag)
+			}
+		}
+		next.ServeHTTP(rw, r)
+	})
+}
+
+// modTimeFS gives every file the same modification time, embedded files
+// have none, so responses carry a Last-Modified.
+type modTimeFS struct {
+	fs.FS
+	modTime time.Time
+}
+
+func (m modTimeFS) Open(name string) (fs.File, error) {
+	f, err := m.FS.Open(name)
+	if err != nil {
+		return nil, err
+	}
+	return modTimeFile{File: f, modTime: m.modTime}, nil
+}
+
+// modTimeFile keeps the Seek and ReadDir of the embedded files, the file
+// server needs them.
+type modTimeFile struct {
+	fs.File
+	modTime time.Time
+}
+
+func (f modTimeFile) Stat() (fs.FileInfo, error) {
+	info, err := f.File.Stat()
+	if err != nil {
+		return nil, err
+	}
+	return modTimeInfo{FileInfo: info, modTime: f.modTime}, nil
+}
+
+func (f modTimeFile) Seek(offset int64, whence int) (int64, error) {
+	seeker, ok := f.File.(io.Seeker)
+	if !ok {
+		return 0, errors.New("seek not supported")
+	}
+	return seeker.Seek(offset, whence)
+}
+
+func (f modTimeFile) ReadDir(n int) ([]fs.DirEntry, error) {
+	dir, ok := f.File.(fs.ReadDirFile)
+	if !ok {
+		return nil, errors.New("not a directory")
+	}
+	return dir.ReadDir(n)
+}
+
+type modTimeInfo struct {
+	fs.FileInfo
+	modTime time.Time
+}
+
+func (i modTimeInfo) ModTime() time.Time { return i.modTime }
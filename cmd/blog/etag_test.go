@@ -0,0 +1,158 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestETags(t *testing.T) {
+	site := testSite()
+	tags, err := newETags(site)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(tags) != len(site) {
+		t.Errorf("got %d tags for %d files", len(tags), len(site))
+	}
+	seen := map[string]string{}
+	for name, tag := range tags {
+		if len(tag) != 34 || tag[0] != '"' || tag[len(tag)-1] != '"' {
+			t.Errorf("tag of %s is %s, want a quoted 32 digits hash", name, tag)
+		}
+		if other, ok := seen[tag]; ok {
+			t.Errorf("%s and %s have the same tag", name, other)
+		}
+		seen[tag] = name
+	}
+}
+
+func TestConditionalRequests(t *testing.T) {
+	modTime := time.Date(2024, 5, 20, 10, 0, 0, 0, time.UTC)
+	site := modTimeFS{FS: testSite(), modTime: modTime}
+	tags, err := newETags(site)
+	if err != nil {
+		t.Fatal(err)
+	}
+	h := tags.handler(precompressed(site, tags, http.FileServerFS(site)))
+
+	tests := []struct {
+		name           string
+		path           string
+		acceptEncoding string
+		header         string
+		value          string
+		code           int
+	}{
+		{
+			name: "no condition",
+			path: "/",
+			code: http.StatusOK,
+		},
+		{
+			name:   "matching tag",
+			path:   "/",
+			header: "If-None-Match",
+			value:  tags["index.html"],
+			code:   http.StatusNotModified,
+		},
+		{
+			name:   "one of the tags",
+			path:   "/blog/post.html",
+			header: "If-None-Match",
+			value:  `"other", ` + tags["blog/post.html"],
+			code:   http.StatusNotModified,
+		},
+		{
+			name:   "any tag",
+			path:   "/images/gopher.png",
+			header: "If-None-Match",
+			value:  "*",
+			code:   http.StatusNotModified,
+		},
+		{
+			name:   "weak tag",
+			path:   "/",
+			header: "If-None-Match",
+			value:  "W/" + tags["index.html"],
+			code:   http.StatusNotModified,
+		},
+		{
+			name:   "other tag",
+			path:   "/",
+			header: "If-None-Match",
+			value:  `"other"`,
+			code:   http.StatusOK,
+		},
+		{
+			name:           "tag of the variant",
+			path:           "/",
+			acceptEncoding: "gzip",
+			header:         "If-None-Match",
+			value:          tags["index.html.gz"],
+			code:           http.StatusNotModified,
+		},
+		{
+			name:           "tag of another variant",
+			path:           "/",
+			acceptEncoding: "gzip",
+			header:         "If-None-Match",
+			value:          tags["index.html"],
+			code:           http.StatusOK,
+		},
+		{
+			name:   "not modified since",
+			path:   "/",
+			header: "If-Modified-Since",
+			value:  modTime.Format(http.TimeFormat),
+			code:   http.StatusNotModified,
+		},
+		{
+			name:           "variant not modified since",
+			path:           "/",
+			acceptEncoding: "br",
+			header:         "If-Modified-Since",
+			value:          modTime.Add(time.Hour).Format(http.TimeFormat),
+			code:           http.StatusNotModified,
+		},
+		{
+			name:   "modified since",
+			path:   "/",
+			header: "If-Modified-Since",
+			value:  modTime.Add(-time.Hour).Format(http.TimeFormat),
+			code:   http.StatusOK,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			r := httptest.NewRequest(http.MethodGet, test.path, nil)
+			if test.acceptEncoding != "" {
+				r.Header.Set("Accept-Encoding", test.acceptEncoding)
+			}
+			if test.header != "" {
+				r.Header.Set(test.header, test.value)
+			}
+			rw := httptest.NewRecorder()
+			h.ServeHTTP(rw, r)
+
+			if rw.Code != test.code {
+				t.Fatalf("got status %d, want %d", rw.Code, test.code)
+			}
+			if rw.Header().Get("Etag") == "" {
+				t.Error("got no ETag")
+			}
+			if rw.Code == http.StatusNotModified {
+				if rw.Body.Len() > 0 {
+					t.Errorf("got a body with a 304: %q", rw.Body)
+				}
+				return
+			}
+			// 304s leave it out, the ETag being enough.
+			if got := rw.Header().Get("Last-Modified"); got != modTime.Format(http.TimeFormat) {
+				t.Errorf("got Last-Modified %q, want %q", got, modTime.Format(http.TimeFormat))
+			}
+		})
+	}
+}
@@ -4,10 +4,82 @@ import (
 	"context"
 	"database/sql"
 	"errors"
+	"fmt"
+	"log"
+	"time"
 
 	_ "github.com/go-sql-driver/mysql"
 )
 
+func main() {
+	db, err := sql.Open("mysql", "user:password@/rate_limiting")
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer db.Close()
+
+	action := func(context.Context) (string, error) {
+		return "done", nil
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second*3)
+	defer cancel()
+
+	// Every replica shares the same bucket, created beforehand.
+	requester := NewSQLTokenRequester(ctx, db, "7b9d3a6e-2f61-4c1e-9d0a-5c8e4f1b2a37")
+	limited := limit(ctx, requester, action)
+
+	start := time.Now()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		default:
+			for i := 0; i < 5; i++ {
+				go func() {
+					result, err := limited(ctx)
+					since := time.Since(start).Milliseconds()
+					fmt.Printf("%vms\t-> result: %v\t\t| error: %v\n", since, result, err)
+				}()
+			}
+			time.Sleep(time.Second)
+		}
+	}
+}
+
+type actionFunc func(context.Context) (string, error)
+
+// region interface
+
+type TokenRequester interface {
+	// RequestToken requests a token from the bucket.
+	// This interface assumes that the implementation knows
+	// to which bucket it should make the token request.
+	RequestToken(ctx context.Context) error
+}
+
+// endregion interface
+
+// region limit
+
+// limit wraps a function and limits its calls over time respecting the
+// max and refill period rate.
+func limit(ctx context.Context, tokenRequester TokenRequester, action actionFunc) func(context.Context) (string, error) {
+	return func(c context.Context) (string, error) {
+		// Using closure to capture the TokenRequester to have access to
+		// the RequestToken method.
+		if err := tokenRequester.RequestToken(c); err != nil {
+			return "", err
+		}
+
+		return action(ctx)
+	}
+}
+
+// endregion limit
+
+// region requester
+
 // SQLTokenRequester implements the TokenRequester interface
 // by using a sql compliant database as persistence layer.
 type SQLTokenRequester struct {
@@ -27,7 +99,7 @@ func NewSQLTokenRequester(ctx context.Context, db *sql.DB, bucketID string) *SQL
 
 // RequestToken requests a token from the shared bucket.
 // This implementation assumes that the token bucket and the stored
-// procedure were created beforehand in the database.
+// procedures were created beforehand in the database.
 func (tr *SQLTokenRequester) RequestToken(ctx context.Context) error {
 	q := `
 		CALL request_token(?);
@@ -48,3 +120,5 @@ func (tr *SQLTokenRequester) RequestToken(ctx context.Context) error {
 
 	return nil
 }
+
+// endregion requester
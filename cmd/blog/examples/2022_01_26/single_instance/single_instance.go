@@ -54,7 +54,7 @@ func limit(ctx context.Context, maxCalls int, refillPeriod time.Duration, action
 	// lets use a mutex to avoid race conditions.
 	var mx sync.Mutex
 	go func() {
-		// Defering the Stop call to make sure we don't leak the ticker.
+		// Deferring the Stop call to make sure we don't leak the ticker.
 		defer ticker.Stop()
 
 		for {
@@ -66,7 +66,7 @@ func limit(ctx context.Context, maxCalls int, refillPeriod time.Duration, action
 				tokens = maxCalls
 				mx.Unlock()
 			case <-ctx.Done():
-				// If the context is cancelled, we should return from the
+				// If the context is canceled, we should return from the
 				// function and avoid leaking the go routine.
 				return
 			}
@@ -97,3 +97,23 @@ func limit(ctx context.Context, maxCalls int, refillPeriod time.Duration, action
 		return action(ctx)
 	}
 }
+
+// Output: (something like this...)
+// 0ms     -> result: done         | error: <nil>
+// 0ms     -> result:              | error: no tokens available
+// 0ms     -> result: done         | error: <nil>
+// 0ms     -> result:              | error: no tokens available
+// 0ms     -> result:              | error: no tokens available
+//         -> TokenBucket: refilling!!
+// 1000ms  -> result: done         | error: <nil>
+// 1000ms  -> result: done         | error: <nil>
+// 1000ms  -> result:              | error: no tokens available
+// 1000ms  -> result:              | error: no tokens available
+// 1000ms  -> result:              | error: no tokens available
+//         -> TokenBucket: refilling!!
+// 2000ms  -> result: done         | error: <nil>
+// 2000ms  -> result: done         | error: <nil>
+// 2000ms  -> result:              | error: no tokens available
+// 2000ms  -> result:              | error: no tokens available
+// 2000ms  -> result:              | error: no tokens available
+//         -> TokenBucket: refilling!!
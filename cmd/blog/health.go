@@ -0,0 +1,101 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"runtime/debug"
+	"strconv"
+	"sync/atomic"
+	"time"
+)
+
+// Set at build time with -ldflags "-X main.version=... -X main.commit=...
+// -X main.contentTime=<unix seconds>", the build info of the binary fills
+// in what is left empty.
+var (
+	version     string
+	commit      string
+	contentTime string
+)
+
+// buildInfo tells what is deployed.
+type buildInfo struct {
+	Version  string `json:"version"`
+	Commit   string `json:"commit"`
+	Modified bool   `json:"modified,omitempty"`
+	// ContentTime is when the embedded site was generated, at the latest:
+	// the commit time when built from a clean checkout, otherwise the
+	// server start, the binary and the site in it being older than that.
+	ContentTime time.Time `json:"content_time"`
+}
+
+func readBuildInfo() buildInfo {
+	start := time.Now().UTC().Truncate(time.Second)
+	b := buildInfo{Version: version, Commit: commit}
+
+	var vcsTime time.Time
+	if info, ok := debug.ReadBuildInfo(); ok {
+		if b.Version == "" {
+			b.Version = info.Main.Version
+		}
+		for _, setting := range info.Settings {
+			switch setting.Key {
+			case "vcs.revision":
+				if b.Commit == "" {
+					b.Commit = setting.Value
+				}
+			case "vcs.modified":
+				b.Modified = setting.Value == "true"
+			case "vcs.time":
+				vcsTime, _ = time.Parse(time.RFC3339, setting.Value)
+			}
+		}
+	}
+	if b.Version == "" {
+		b.Version = "(devel)"
+	}
+
+	switch sec, err := strconv.ParseInt(contentTime, 10, 64); {
+	case err == nil:
+		b.ContentTime = time.Unix(sec, 0).UTC()
+	case b.Modified || vcsTime.IsZero():
+		b.ContentTime = start
+	default:
+		b.ContentTime = vcsTime
+	}
+	return b
+}
+
+// health answers the liveness and readiness checks. The server stops
+// being ready once it starts shutting down, while it drains the requests
+// being served.
+type health struct {
+	info         buildInfo
+	shuttingDown atomic.Bool
+}
+
+type healthStatus struct {
+	Status string `json:"status"`
+	buildInfo
+}
+
+// live is up as long as the server answers.
+func (h *health) live(rw http.ResponseWriter, r *http.Request) {
+	h.write(rw, http.StatusOK, "ok")
+}
+
+// ready is down while shutting down.
+func (h *health) ready(rw http.ResponseWriter, r *http.Request) {
+	if h.shuttingDown.Load() {
+		h.write(rw, http.StatusServiceUnavailable, "shutting down")
+		return
+	}
+	h.write(rw, http.StatusOK, "ok")
+}
+
+func (h *health) write(rw http.ResponseWriter, code int, status string) {
+	rw.Header().Set("Content-Type", "application/json")
+	rw.Header().Set("Cache-Control", "no-store")
+	rw.WriteHeader(code)
+	json.NewEncoder(rw).Encode(healthStatus{Status: status, buildInfo: h.info})
+}
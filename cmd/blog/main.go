@@ -5,87 +5,187 @@ import (
 	"embed"
 	"flag"
 	"io/fs"
-	"log"
+	"log/slog"
 	"net/http"
 	"os"
 	"os/signal"
+	"path"
+	"slices"
 	"sync"
 	"syscall"
 	"time"
+
+	"github.com/cesarFuhr/cesarfuhr.dev-app/internal/fingerprint"
+	"github.com/cesarFuhr/cesarfuhr.dev-app/internal/gen"
 )
 
-//go:generate go run ../gen/main.go
+// The generator also reads its flags from $GENFLAGS, e.g.
+// GENFLAGS="-drafts -force" go generate ./...
+//
+//go:generate go run ../gen -config ../../site.json
 
 func main() {
 	if err := run(); err != nil {
-		log.Fatalf("app has run into an fatal error %v", err)
+		slog.Error("app has run into a fatal error", "err", err)
+		os.Exit(1)
 	}
 }
 
 func run() error {
-	logger := log.New(os.Stdout, "APP : ", log.Lmicroseconds|log.Lmsgprefix)
 	ctx := context.Background()
 
 	httpPort := flag.String("HTTP_PORT", "8080", "app http port")
+	dev := flag.Bool("dev", false, "serve the site from disk, rebuilding and reloading it on changes")
+	siteConfig := flag.String("config", "site.json", "site config file, used in dev mode")
+	devOutput := flag.String("dev-output", "", "directory the site is built into in dev mode, a temporary one when empty")
+	logFormat := flag.String("log-format", "text", "log output format, text or json")
+	logLevel := flag.String("log-level", "info", "minimum log level: debug, info, warn or error")
+	drainDelay := flag.Duration("drain-delay", 0, "time between failing the readiness check and shutting down, to let the proxy stop routing here")
+	metricsAddr := flag.String("metrics-addr", "", "address serving the prometheus metrics, apart from the site, none when empty")
 	flag.Parse()
 
+	logger, err := newLogger(os.Stdout, *logFormat, *logLevel)
+	if err != nil {
+		return err
+	}
+	slog.SetDefault(logger)
+
 	var wg sync.WaitGroup
+	m := newMetrics()
+
+	subPublic, err := fs.Sub(public, "public")
+	if err != nil {
+		return err
+	}
+	subPublic = siteFS{subPublic}
+	tags, err := newETags(subPublic)
+	if err != nil {
+		return err
+	}
+	h := &health{info: readBuildInfo()}
+	site := modTimeFS{FS: subPublic, modTime: h.info.ContentTime}
+	httpServer := newServer(logger, m, h, tags.handler(precompressed(site, tags, http.FileServerFS(site))))
+
+	if *dev {
+		devSite, err := newDevSite(logger, *siteConfig, *devOutput)
+		if err != nil {
+			return err
+		}
+		defer devSite.removeOutput()
+		httpServer = newServer(logger, m, h, devSite.handler())
+		httpServer.RegisterOnShutdown(devSite.close)
 
-	httpServer := newServer(logger)
+		watchCtx, cancel := context.WithCancel(ctx)
+		defer cancel()
+		go devSite.watch(watchCtx)
+	}
 	httpServer.Addr = ":" + *httpPort
 
 	wg.Add(1)
 	go func() {
-		logger.Println("started serving http")
+		logger.Info("started serving http", "addr", httpServer.Addr, "version", h.info.Version, "commit", h.info.Commit)
 		if err := httpServer.ListenAndServe(); err != nil {
-			logger.Printf("stoped serving http : %v", err)
+			logger.Info("stopped serving http", "err", err)
 		}
 		wg.Done()
 	}()
 
+	var metricsServer *http.Server
+	if *metricsAddr != "" {
+		metricsServer = newMetricsServer(m)
+		metricsServer.Addr = *metricsAddr
+
+		wg.Add(1)
+		go func() {
+			logger.Info("started serving metrics", "addr", metricsServer.Addr)
+			if err := metricsServer.ListenAndServe(); err != nil {
+				logger.Info("stopped serving metrics", "err", err)
+			}
+			wg.Done()
+		}()
+	}
+
 	sigs := make(chan os.Signal, 1)
 	signal.Notify(sigs, syscall.SIGINT, syscall.SIGTERM)
 
 	go func() {
 		s := <-sigs
-		logger.Printf("shuting down : SIG %v", s)
+		logger.Info("shutting down", "signal", s.String())
+		h.shuttingDown.Store(true)
+		time.Sleep(*drainDelay)
 
 		c, cancel := context.WithTimeout(ctx, time.Second*5)
 		defer cancel()
 
 		httpServer.Shutdown(c)
+		if metricsServer != nil {
+			metricsServer.Shutdown(c)
+		}
 	}()
 
 	wg.Wait()
 	return nil
 }
 
-//go:embed public/*
+// Embedding the directory, not public/*, leaves out hidden files like
+// the generator manifest.
+//
+//go:embed public
 var public embed.FS
 
-func newServer(logger *log.Logger) *http.Server {
-	subPublic, err := fs.Sub(public, "public")
-	if err != nil {
-		panic(err)
+// siteFS is the embedded site without the placeholder.
+type siteFS struct {
+	fs.FS
+}
+
+func (s siteFS) Open(name string) (fs.File, error) {
+	if name == gen.PlaceholderFile {
+		return nil, &fs.PathError{Op: "open", Path: name, Err: fs.ErrNotExist}
+	}
+	return s.FS.Open(name)
+}
+
+func (s siteFS) ReadDir(name string) ([]fs.DirEntry, error) {
+	entries, err := fs.ReadDir(s.FS, name)
+	if name == "." {
+		entries = slices.DeleteFunc(entries, func(e fs.DirEntry) bool { return e.Name() == gen.PlaceholderFile })
 	}
-	publicHandler := http.FileServerFS(subPublic)
+	return entries, err
+}
 
+func newServer(logger *slog.Logger, m *metrics, h *health, publicHandler http.Handler) *http.Server {
 	mux := http.NewServeMux()
-	mux.Handle("/", loggerMiddleware(logger, publicHandler))
+	// Checks run every few seconds, they are left out of the logs and
+	// the metrics.
+	mux.HandleFunc("GET /healthz", h.live)
+	mux.HandleFunc("GET /readyz", h.ready)
+	mux.Handle("/", loggerMiddleware(logger, m.middleware(cacheMiddleware(publicHandler))))
 
 	return &http.Server{Handler: mux}
 }
 
-func loggerMiddleware(logger *log.Logger, h http.Handler) http.Handler {
-	return http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
-		logger.Println("incoming request    : ", r.Method, r.URL.Path)
+// newMetricsServer serves the metrics on their own listener, they can be
+// kept private while the site is public.
+func newMetricsServer(m *metrics) *http.Server {
+	mux := http.NewServeMux()
+	mux.Handle("GET /metrics", m)
 
-		now := time.Now()
-		defer func() {
-			logger.Println("completed request   : ", r.Method, r.URL.Path, time.Since(now))
-		}()
+	return &http.Server{Handler: mux}
+}
 
-		rw.Header().Add("Cache-Control", "max-age=3600")
+// cacheMiddleware lets fingerprinted assets be cached forever, their names
+// change with their content. Pages are revalidated on every visit, so a
+// deploy is seen right away.
+func cacheMiddleware(h http.Handler) http.Handler {
+	return http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+		switch ext := path.Ext(r.URL.Path); {
+		case fingerprint.Is(r.URL.Path):
+			rw.Header().Set("Cache-Control", "public, max-age=31536000, immutable")
+		case ext == ".html" || ext == "":
+			rw.Header().Set("Cache-Control", "no-cache")
+		default:
+			rw.Header().Set("Cache-Control", "max-age=3600")
+		}
 		h.ServeHTTP(rw, r)
 	})
 }
@@ -0,0 +1,149 @@
+package main
+
+import (
+	"cmp"
+	"fmt"
+	"io"
+	"maps"
+	"net/http"
+	"path"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// durationBuckets are the upper bounds, in seconds, of the latency
+// histogram. Files are served from memory, most requests are fast.
+var durationBuckets = []float64{0.0005, 0.001, 0.0025, 0.005, 0.01, 0.025, 0.05, 0.1, 0.25, 0.5, 1}
+
+// metrics of the requests served, exposed in the Prometheus text format.
+// Paths are grouped to keep the number of series small.
+type metrics struct {
+	inFlight atomic.Int64
+
+	mu        sync.Mutex
+	requests  map[requestLabels]uint64
+	bytes     map[string]uint64
+	durations map[string]*histogram
+}
+
+type requestLabels struct {
+	group string
+	code  int
+}
+
+type histogram struct {
+	counts []uint64 // by bucket, not cumulative
+	count  uint64
+	sum    float64
+}
+
+func newMetrics() *metrics {
+	return &metrics{
+		requests:  map[requestLabels]uint64{},
+		bytes:     map[string]uint64{},
+		durations: map[string]*histogram{},
+	}
+}
+
+// pathGroup is the kind of content at p.
+func pathGroup(p string) string {
+	switch ext := path.Ext(p); {
+	case strings.HasPrefix(p, "/_dev/"):
+		return "dev"
+	case strings.HasPrefix(p, "/blog/"):
+		return "posts"
+	case strings.HasPrefix(p, "/tags/"):
+		return "tags"
+	case strings.HasPrefix(p, "/css/"), strings.HasPrefix(p, "/js/"), strings.HasPrefix(p, "/images/"):
+		return "assets"
+	case ext == ".rss" || ext == ".atom":
+		return "feeds"
+	case p == "/sitemap.xml" || p == "/robots.txt":
+		return "crawlers"
+	case ext == "" || ext == ".html":
+		return "pages"
+	default:
+		return "other"
+	}
+}
+
+// middleware records the requests served by h.
+func (m *metrics) middleware(h http.Handler) http.Handler {
+	return http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+		m.inFlight.Add(1)
+		defer m.inFlight.Add(-1)
+
+		start := time.Now()
+		rec := &responseRecorder{ResponseWriter: rw}
+		h.ServeHTTP(rec, r)
+		m.observe(pathGroup(r.URL.Path), rec.statusCode(), rec.size, time.Since(start))
+	})
+}
+
+func (m *metrics) observe(group string, code int, size int64, d time.Duration) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.requests[requestLabels{group: group, code: code}]++
+	m.bytes[group] += uint64(size)
+
+	h, ok := m.durations[group]
+	if !ok {
+		h = &histogram{counts: make([]uint64, len(durationBuckets))}
+		m.durations[group] = h
+	}
+	seconds := d.Seconds()
+	if i, _ := slices.BinarySearch(durationBuckets, seconds); i < len(durationBuckets) {
+		h.counts[i]++
+	}
+	h.count++
+	h.sum += seconds
+}
+
+// ServeHTTP writes the metrics in the Prometheus text exposition format.
+func (m *metrics) ServeHTTP(rw http.ResponseWriter, r *http.Request) {
+	rw.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+	m.write(rw)
+}
+
+func (m *metrics) write(w io.Writer) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	fmt.Fprintln(w, "# HELP blog_http_requests_total Requests served, by path group and status code.")
+	fmt.Fprintln(w, "# TYPE blog_http_requests_total counter")
+	requests := slices.SortedFunc(maps.Keys(m.requests), func(a, b requestLabels) int {
+		return cmp.Or(strings.Compare(a.group, b.group), cmp.Compare(a.code, b.code))
+	})
+	for _, l := range requests {
+		fmt.Fprintf(w, "blog_http_requests_total{group=%q,code=\"%d\"} %d\n", l.group, l.code, m.requests[l])
+	}
+
+	fmt.Fprintln(w, "# HELP blog_http_response_bytes_total Response body bytes served, by path group.")
+	fmt.Fprintln(w, "# TYPE blog_http_response_bytes_total counter")
+	for _, group := range slices.Sorted(maps.Keys(m.bytes)) {
+		fmt.Fprintf(w, "blog_http_response_bytes_total{group=%q} %d\n", group, m.bytes[group])
+	}
+
+	fmt.Fprintln(w, "# HELP blog_http_request_duration_seconds Time to serve requests, by path group.")
+	fmt.Fprintln(w, "# TYPE blog_http_request_duration_seconds histogram")
+	for _, group := range slices.Sorted(maps.Keys(m.durations)) {
+		h := m.durations[group]
+		var cumulative uint64
+		for i, le := range durationBuckets {
+			cumulative += h.counts[i]
+			fmt.Fprintf(w, "blog_http_request_duration_seconds_bucket{group=%q,le=%q} %d\n", group, strconv.FormatFloat(le, 'g', -1, 64), cumulative)
+		}
+		fmt.Fprintf(w, "blog_http_request_duration_seconds_bucket{group=%q,le=\"+Inf\"} %d\n", group, h.count)
+		fmt.Fprintf(w, "blog_http_request_duration_seconds_sum{group=%q} %s\n", group, strconv.FormatFloat(h.sum, 'g', -1, 64))
+		fmt.Fprintf(w, "blog_http_request_duration_seconds_count{group=%q} %d\n", group, h.count)
+	}
+
+	fmt.Fprintln(w, "# HELP blog_http_requests_in_flight Requests being served.")
+	fmt.Fprintln(w, "# TYPE blog_http_requests_in_flight gauge")
+	fmt.Fprintf(w, "blog_http_requests_in_flight %d\n", m.inFlight.Load())
+}
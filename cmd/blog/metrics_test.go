@@ -0,0 +1,130 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestPathGroup(t *testing.T) {
+	tests := []struct {
+		path  string
+		group string
+	}{
+		{path: "/", group: "pages"},
+		{path: "/about.html", group: "pages"},
+		{path: "/blog/first.html", group: "posts"},
+		{path: "/tags/go.html", group: "tags"},
+		{path: "/css/style.0123abcd.css", group: "assets"},
+		{path: "/images/og/first.png", group: "assets"},
+		{path: "/index.rss", group: "feeds"},
+		{path: "/index.atom", group: "feeds"},
+		{path: "/sitemap.xml", group: "crawlers"},
+		{path: "/robots.txt", group: "crawlers"},
+		{path: "/_dev/events", group: "dev"},
+		{path: "/favicon.ico", group: "other"},
+	}
+	for _, test := range tests {
+		if got := pathGroup(test.path); got != test.group {
+			t.Errorf("pathGroup(%q) = %q, want %q", test.path, got, test.group)
+		}
+	}
+}
+
+func TestMetricsExposition(t *testing.T) {
+	m := newMetrics()
+	m.observe("posts", 200, 100, 300*time.Microsecond)
+	m.observe("posts", 200, 50, 3*time.Millisecond)
+	m.observe("posts", 404, 10, 2*time.Second)
+	m.observe("assets", 304, 0, time.Millisecond)
+
+	rw := httptest.NewRecorder()
+	m.ServeHTTP(rw, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+
+	if got := rw.Header().Get("Content-Type"); got != "text/plain; version=0.0.4; charset=utf-8" {
+		t.Errorf("got Content-Type %q", got)
+	}
+
+	want := `# HELP blog_http_requests_total Requests served, by path group and status code.
+# TYPE blog_http_requests_total counter
+blog_http_requests_total{group="assets",code="304"} 1
+blog_http_requests_total{group="posts",code="200"} 2
+blog_http_requests_total{group="posts",code="404"} 1
+# HELP blog_http_response_bytes_total Response body bytes served, by path group.
+# TYPE blog_http_response_bytes_total counter
+blog_http_response_bytes_total{group="assets"} 0
+blog_http_response_bytes_total{group="posts"} 160
+# HELP blog_http_request_duration_seconds Time to serve requests, by path group.
+# TYPE blog_http_request_duration_seconds histogram
+blog_http_request_duration_seconds_bucket{group="assets",le="0.0005"} 0
+blog_http_request_duration_seconds_bucket{group="assets",le="0.001"} 1
+blog_http_request_duration_seconds_bucket{group="assets",le="0.0025"} 1
+blog_http_request_duration_seconds_bucket{group="assets",le="0.005"} 1
+blog_http_request_duration_seconds_bucket{group="assets",le="0.01"} 1
+blog_http_request_duration_seconds_bucket{group="assets",le="0.025"} 1
+blog_http_request_duration_seconds_bucket{group="assets",le="0.05"} 1
+blog_http_request_duration_seconds_bucket{group="assets",le="0.1"} 1
+blog_http_request_duration_seconds_bucket{group="assets",le="0.25"} 1
+blog_http_request_duration_seconds_bucket{group="assets",le="0.5"} 1
+blog_http_request_duration_seconds_bucket{group="assets",le="1"} 1
+blog_http_request_duration_seconds_bucket{group="assets",le="+Inf"} 1
+blog_http_request_duration_seconds_sum{group="assets"} 0.001
+blog_http_request_duration_seconds_count{group="assets"} 1
+blog_http_request_duration_seconds_bucket{group="posts",le="0.0005"} 1
+blog_http_request_duration_seconds_bucket{group="posts",le="0.001"} 1
+blog_http_request_duration_seconds_bucket{group="posts",le="0.0025"} 1
+blog_http_request_duration_seconds_bucket{group="posts",le="0.005"} 2
+blog_http_request_duration_seconds_bucket{group="posts",le="0.01"} 2
+blog_http_request_duration_seconds_bucket{group="posts",le="0.025"} 2
+blog_http_request_duration_seconds_bucket{group="posts",le="0.05"} 2
+blog_http_request_duration_seconds_bucket{group="posts",le="0.1"} 2
+blog_http_request_duration_seconds_bucket{group="posts",le="0.25"} 2
+blog_http_request_duration_seconds_bucket{group="posts",le="0.5"} 2
+blog_http_request_duration_seconds_bucket{group="posts",le="1"} 2
+blog_http_request_duration_seconds_bucket{group="posts",le="+Inf"} 3
+blog_http_request_duration_seconds_sum{group="posts"} 2.0033
+blog_http_request_duration_seconds_count{group="posts"} 3
+# HELP blog_http_requests_in_flight Requests being served.
+# TYPE blog_http_requests_in_flight gauge
+blog_http_requests_in_flight 0
+`
+	if got := rw.Body.String(); got != want {
+		t.Errorf("got:\n%s\nwant:\n%s", got, want)
+	}
+}
+
+func TestMetricsMiddleware(t *testing.T) {
+	m := newMetrics()
+	var inFlight int64
+	h := m.middleware(http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+		inFlight = m.inFlight.Load()
+		if r.URL.Path == "/missing" {
+			http.NotFound(rw, r)
+			return
+		}
+		rw.Write([]byte("hello"))
+	}))
+
+	for _, path := range []string{"/blog/a.html", "/blog/b.html", "/missing"} {
+		h.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, path, nil))
+	}
+	if inFlight != 1 {
+		t.Errorf("got %d requests in flight while serving, want 1", inFlight)
+	}
+
+	var b strings.Builder
+	m.write(&b)
+	for _, line := range []string{
+		`blog_http_requests_total{group="posts",code="200"} 2`,
+		`blog_http_requests_total{group="pages",code="404"} 1`,
+		`blog_http_response_bytes_total{group="posts"} 10`,
+		`blog_http_request_duration_seconds_count{group="posts"} 2`,
+		`blog_http_requests_in_flight 0`,
+	} {
+		if !strings.Contains(b.String(), line+"\n") {
+			t.Errorf("missing %s in:\n%s", line, b.String())
+		}
+	}
+}
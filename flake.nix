@@ -30,6 +30,7 @@
               pkgs.go-tools
               pkgs.gopls
               pkgs.gnumake
+              pkgs.brotli
             ];
           };
         }
@@ -51,6 +52,16 @@
 
             env.CGO_ENABLED = 0;
 
+            # The source has no .git, the health checks report these.
+            ldflags = [
+              "-X main.version=${inputs.self.shortRev or "dirty"}"
+              "-X main.commit=${inputs.self.rev or "dirty"}"
+              "-X main.contentTime=${toString inputs.self.lastModified}"
+            ];
+
+            # The generator writes brotli variants of the assets with it.
+            nativeBuildInputs = [ pkgs.brotli ];
+
             preBuild = ''
               make pre
             '';
@@ -60,7 +71,16 @@
             name = name;
             tag = "latest";
             config = {
-              Cmd = [ "${blog}/bin/${name}" ];
+              Cmd = [
+                "${blog}/bin/${name}"
+                "-log-format"
+                "json"
+                "-metrics-addr"
+                ":9091"
+                # Longer than the interval of the readiness check.
+                "-drain-delay"
+                "20s"
+              ];
             };
           };
         }
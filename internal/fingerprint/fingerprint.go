@@ -0,0 +1,30 @@
+// Package fingerprint names files after a hash of their content, so they
+// can be cached forever: a changed file gets a new name.
+package fingerprint
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"path"
+	"regexp"
+	"strings"
+)
+
+// Len is the number of hex digits of the hash in the names.
+const Len = 8
+
+var fingerprinted = regexp.MustCompile(fmt.Sprintf(`\.[0-9a-f]{%d}\.[a-z]+$`, Len))
+
+// Name is the fingerprinted version of name, for a file with content:
+// "style.css" becomes "style.<hash>.css".
+func Name(name string, content []byte) string {
+	sum := sha256.Sum256(content)
+	ext := path.Ext(name)
+	return strings.TrimSuffix(name, ext) + "." + hex.EncodeToString(sum[:])[:Len] + ext
+}
+
+// Is tells if the file name has a content hash in it, as given by Name.
+func Is(name string) bool {
+	return fingerprinted.MatchString(name)
+}
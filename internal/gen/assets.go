@@ -0,0 +1,75 @@
+package gen
+
+import (
+	"io/fs"
+	"maps"
+	"os"
+	"path/filepath"
+	"slices"
+
+	"github.com/cesarFuhr/cesarfuhr.dev-app/internal/fingerprint"
+)
+
+// Assets are served under fingerprinted names, see package fingerprint.
+// The original names stay around for links from outside the site.
+var fingerprintedExts = []string{".css", ".js", ".png", ".jpg", ".jpeg", ".gif", ".svg", ".ico", ".webp"}
+
+// fingerprintAssets gives the assets copied to out their fingerprinted
+// name, returning a key identifying all of them. Pages point to the
+// fingerprinted names, so they depend on every asset.
+func (b *builder) fingerprintAssets(out string) (string, error) {
+	err := filepath.WalkDir(out, func(name string, entry fs.DirEntry, err error) error {
+		if err != nil || entry.IsDir() || !slices.Contains(fingerprintedExts, filepath.Ext(name)) {
+			return err
+		}
+
+		rel, err := filepath.Rel(out, name)
+		if err != nil {
+			return err
+		}
+		return b.fingerprintAsset(out, "/"+filepath.ToSlash(rel))
+	})
+	if err != nil {
+		return "", err
+	}
+
+	key := ""
+	for _, path := range slices.Sorted(maps.Keys(b.assets)) {
+		key = hashOf(key, path, b.assets[path])
+	}
+	return key, nil
+}
+
+// fingerprintAsset links, or copies, the asset at path, in out, to its
+// fingerprinted name. Assets generated by the build, like the preview cards, go through
+// it once written.
+func (b *builder) fingerprintAsset(out, path string) error {
+	name := filepath.Join(out, filepath.FromSlash(path))
+	content, err := os.ReadFile(name)
+	if err != nil {
+		return err
+	}
+
+	fingerprinted := fingerprint.Name(path, content)
+	dst := filepath.Join(out, filepath.FromSlash(fingerprinted))
+
+	// A hard link saves the space, a copy does the same where there are
+	// none.
+	if err := os.Link(name, dst); err != nil {
+		err = os.WriteFile(dst, content, 0644)
+		if err != nil {
+			return err
+		}
+	}
+	b.assets[path] = fingerprinted
+	return nil
+}
+
+// assetURL is the fingerprinted path of the asset at path, or path itself
+// when it is not an asset.
+func (b *builder) assetURL(path string) string {
+	if fingerprinted, ok := b.assets[path]; ok {
+		return fingerprinted
+	}
+	return path
+}
@@ -0,0 +1,185 @@
+package gen
+
+import (
+	"bytes"
+	"image"
+	"image/color"
+	"image/draw"
+	"image/png"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"golang.org/x/text/unicode/norm"
+)
+
+// Social preview cards, the size recommended by most social media.
+const (
+	cardWidth   = 1200
+	cardHeight  = 630
+	cardPadding = 80
+	cardDir     = "images/og/"
+)
+
+// Colors from the dark theme.
+var (
+	cardBackground = color.RGBA{0x26, 0x27, 0x30, 0xff}
+	cardForeground = color.RGBA{0xfb, 0xfb, 0xfb, 0xff}
+	cardSecondary  = color.RGBA{0x89, 0x9b, 0xa9, 0xff}
+	cardAccent     = color.RGBA{0xb8, 0x97, 0x2c, 0xff}
+)
+
+// cardPath is where the preview card of a post is written.
+func cardPath(p page) string {
+	return "/" + cardDir + strings.TrimSuffix(p.Dest, ".html") + ".png"
+}
+
+// card renders the preview image of a post: the date, the title and the
+// site branding, with logo in the corner when there is one.
+func (b *builder) card(p page, logo image.Image) ([]byte, error) {
+	img := image.NewRGBA(image.Rect(0, 0, cardWidth, cardHeight))
+	draw.Draw(img, img.Bounds(), image.NewUniform(cardBackground), image.Point{}, draw.Src)
+	draw.Draw(img, image.Rect(0, 0, 24, cardHeight), image.NewUniform(cardAccent), image.Point{}, draw.Src)
+
+	drawText(img, cardPadding, cardPadding, 4, cardSecondary, longDate(p.Date))
+
+	lines, scale := fitTitle(p.Title)
+	for i, line := range lines {
+		drawText(img, cardPadding, 170+i*glyphLineHeight*scale, scale, cardForeground, line)
+	}
+
+	drawText(img, cardPadding, cardHeight-cardPadding-7*5, 5, cardAccent, b.site.Title)
+
+	if logo != nil {
+		// Nearest neighbor scaling, the gopher is pixel art anyway.
+		const size = 160
+		bounds := logo.Bounds()
+		origin := image.Pt(cardWidth-cardPadding-size, cardHeight-cardPadding-size+20)
+		for y := range size {
+			for x := range size {
+				c := logo.At(bounds.Min.X+x*bounds.Dx()/size, bounds.Min.Y+y*bounds.Dy()/size)
+				img.Set(origin.X+x, origin.Y+y, blend(img.At(origin.X+x, origin.Y+y), c))
+			}
+		}
+	}
+
+	var out bytes.Buffer
+	if err := png.Encode(&out, img); err != nil {
+		return nil, err
+	}
+	return out.Bytes(), nil
+}
+
+// Glyphs are 5x7, plus spacing.
+const (
+	glyphAdvance    = 6
+	glyphLineHeight = 10
+)
+
+// drawText writes s with its top left corner at x, y, each font dot being
+// a scale x scale square.
+func drawText(img draw.Image, x, y, scale int, c color.Color, s string) {
+	fill := image.NewUniform(c)
+	for _, r := range asciiFold(s) {
+		glyph := font5x7['?'-' ']
+		if r >= ' ' && r <= '~' {
+			glyph = font5x7[r-' ']
+		}
+
+		for col, bits := range glyph {
+			for row := range 7 {
+				if bits&(1<<row) == 0 {
+					continue
+				}
+				dot := image.Rect(x+col*scale, y+row*scale, x+(col+1)*scale, y+(row+1)*scale)
+				draw.Draw(img, dot, fill, image.Point{}, draw.Src)
+			}
+		}
+		x += glyphAdvance * scale
+	}
+}
+
+// asciiFold drops accents, the font only has ASCII glyphs.
+func asciiFold(s string) string {
+	var b strings.Builder
+	for _, r := range norm.NFD.String(s) {
+		if !unicode.Is(unicode.Mn, r) {
+			b.WriteRune(r)
+		}
+	}
+	return b.String()
+}
+
+// Titles are written in at most titleLines lines, with the biggest
+// scale fitting them, down to titleMinScale.
+const (
+	titleLines    = 4
+	titleMaxScale = 9
+	titleMinScale = 4
+)
+
+// fitTitle wraps title in the lines drawn on the card, at the returned
+// scale. Titles too long even for the smallest scale are cut, ending in
+// an ellipsis.
+func fitTitle(title string) ([]string, int) {
+	scale := titleMaxScale
+	for {
+		width := (cardWidth - 2*cardPadding) / (glyphAdvance * scale)
+		lines := wrap(title, width)
+		if len(lines) <= titleLines {
+			return lines, scale
+		}
+		if scale > titleMinScale {
+			scale--
+			continue
+		}
+
+		// The font has no ellipsis glyph.
+		last := []rune(lines[titleLines-1])
+		last = last[:min(len(last), width-3)]
+		lines[titleLines-1] = strings.TrimRight(string(last), " ") + "..."
+		return lines[:titleLines], scale
+	}
+}
+
+// wrap breaks s in lines of at most width characters, splitting words
+// only when they are longer than a line.
+func wrap(s string, width int) []string {
+	var lines []string
+	var line string
+	for _, field := range strings.Fields(asciiFold(s)) {
+		word := []rune(field)
+		for len(word) > width {
+			if line != "" {
+				lines = append(lines, line)
+				line = ""
+			}
+			lines = append(lines, string(word[:width]))
+			word = word[width:]
+		}
+
+		switch {
+		case line == "":
+			line = string(word)
+		case utf8.RuneCountInString(line)+1+len(word) <= width:
+			line += " " + string(word)
+		default:
+			lines = append(lines, line)
+			line = string(word)
+		}
+	}
+	if line != "" {
+		lines = append(lines, line)
+	}
+	return lines
+}
+
+// blend draws c over bg, using the alpha of c.
+func blend(bg, c color.Color) color.Color {
+	r, g, b, a := c.RGBA()
+	br, bgg, bb, _ := bg.RGBA()
+	mix := func(fg, bg uint32) uint8 {
+		return uint8((fg + bg*(0xffff-a)/0xffff) >> 8)
+	}
+	return color.RGBA{mix(r, br), mix(g, bgg), mix(b, bb), 0xff}
+}
@@ -0,0 +1,60 @@
+package gen
+
+import (
+	"slices"
+	"strings"
+	"testing"
+	"unicode/utf8"
+)
+
+func TestWrap(t *testing.T) {
+	tests := []struct {
+		s     string
+		width int
+		want  []string
+	}{
+		{s: "", width: 10, want: nil},
+		{s: "Distributed rate limiting in Go", width: 12, want: []string{"Distributed", "rate", "limiting in", "Go"}},
+		{s: "  spaces   between  ", width: 20, want: []string{"spaces between"}},
+		{s: "a supercalifragilistic word", width: 8, want: []string{"a", "supercal", "ifragili", "stic", "word"}},
+		{s: "Olá, café com açúcar", width: 9, want: []string{"Ola, cafe", "com", "acucar"}},
+		{s: "日本語のタイトル", width: 3, want: []string{"日本語", "のタイ", "トル"}},
+	}
+	for _, test := range tests {
+		if got := wrap(test.s, test.width); !slices.Equal(got, test.want) {
+			t.Errorf("wrap(%q, %d) = %q, want %q", test.s, test.width, got, test.want)
+		}
+	}
+}
+
+func TestFitTitle(t *testing.T) {
+	tests := []struct {
+		title string
+		lines int
+		scale int
+		cut   bool
+	}{
+		{title: "Short", lines: 1, scale: titleMaxScale},
+		{title: "How hard could it be to code a simple HTTPS server with Go?", lines: 4, scale: titleMaxScale},
+		{title: strings.Repeat("word ", 25), lines: 4, scale: titleMinScale + 1},
+		{title: strings.Repeat("word ", 40), lines: 4, scale: titleMinScale, cut: true},
+		{title: strings.Repeat("é", 500), lines: 4, scale: titleMinScale, cut: true},
+	}
+	for _, test := range tests {
+		lines, scale := fitTitle(test.title)
+		if len(lines) != test.lines || scale != test.scale {
+			t.Errorf("fitTitle(%q) has %d lines at scale %d, want %d at %d", test.title, len(lines), scale, test.lines, test.scale)
+			continue
+		}
+
+		width := (cardWidth - 2*cardPadding) / (glyphAdvance * scale)
+		for _, line := range lines {
+			if n := utf8.RuneCountInString(line); n > width {
+				t.Errorf("fitTitle(%q) has a line of %d characters, more than %d", test.title, n, width)
+			}
+		}
+		if cut := strings.HasSuffix(lines[len(lines)-1], "..."); cut != test.cut {
+			t.Errorf("fitTitle(%q) ends with %q, want cut %v", test.title, lines[len(lines)-1], test.cut)
+		}
+	}
+}
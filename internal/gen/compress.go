@@ -0,0 +1,102 @@
+package gen
+
+import (
+	"bytes"
+	"compress/gzip"
+	"io/fs"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"slices"
+	"sync"
+)
+
+// Text files are served precompressed, the server picks the variant the
+// client accepts, so requests cost no compression.
+var compressedExts = []string{".html", ".css", ".js", ".svg", ".xml", ".txt", ".json", ".rss", ".atom"}
+
+// compressMinSize is the size below which compressing is not worth it,
+// headers alone take about as much.
+const compressMinSize = 512
+
+// encoding is a precompressed variant, written next to the file with ext
+// appended to its name.
+type encoding struct {
+	ext      string
+	compress func([]byte) ([]byte, error)
+}
+
+// brotliPath is looked up once, the dev server builds on every change.
+var (
+	brotliPath       = sync.OnceValues(func() (string, error) { return exec.LookPath("brotli") })
+	brotliWarnedOnce sync.Once
+)
+
+// encodings available to this build. There is no brotli encoder in the
+// standard library, brotli variants are only written when the brotli
+// command is installed, a warning tells when they are not, the first
+// time only.
+func (b *builder) encodings() []encoding {
+	encs := []encoding{{ext: ".gz", compress: gzipBytes}}
+	brotli, err := brotliPath()
+	if err != nil {
+		brotliWarnedOnce.Do(func() {
+			b.site.warnf("brotli is not installed, only gzip variants are written")
+		})
+		return encs
+	}
+	return append(encs, encoding{ext: ".br", compress: func(content []byte) ([]byte, error) {
+		cmd := exec.Command(brotli, "--stdout", "--best", "-")
+		cmd.Stdin = bytes.NewReader(content)
+		return cmd.Output()
+	}})
+}
+
+// compressAssets writes the compressed variants of the text files in out.
+// Pages link to the fingerprinted assets, the original names are left
+// uncompressed.
+func (b *builder) compressAssets(probs *problems, out string) {
+	encs := b.encodings()
+	err := filepath.WalkDir(out, func(name string, entry fs.DirEntry, err error) error {
+		if err != nil || entry.IsDir() || !slices.Contains(compressedExts, filepath.Ext(name)) {
+			return err
+		}
+
+		rel, err := filepath.Rel(out, name)
+		if err != nil {
+			return err
+		}
+		if _, ok := b.assets["/"+filepath.ToSlash(rel)]; ok {
+			return nil
+		}
+
+		content, err := os.ReadFile(name)
+		if err != nil || len(content) < compressMinSize {
+			return err
+		}
+
+		for _, enc := range encs {
+			err := b.writeOutput(out, filepath.ToSlash(rel)+enc.ext, hashOf(string(content), enc.ext), func() ([]byte, error) {
+				return enc.compress(content)
+			})
+			probs.add(name, err)
+		}
+		return nil
+	})
+	probs.add(out, err)
+}
+
+func gzipBytes(b []byte) ([]byte, error) {
+	var buf bytes.Buffer
+	w, err := gzip.NewWriterLevel(&buf, gzip.BestCompression)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := w.Write(b); err != nil {
+		return nil, err
+	}
+	if err := w.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
@@ -0,0 +1,163 @@
+package gen
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// Config describes the site being generated. It is loaded from a JSON
+// file, then any flag explicitly set overrides it.
+//
+// Relative directories in the file are resolved against the directory
+// the file is in, flags are resolved against the working directory, so
+// the generator behaves the same no matter where it runs from.
+type Config struct {
+	Title        string `json:"title"`
+	Description  string `json:"description"`
+	Author       string `json:"author"`
+	Email        string `json:"email"`
+	BaseURL      string `json:"base_url"`
+	Image        string `json:"image"`
+	SourceDir    string `json:"source_dir"`
+	OutputDir    string `json:"output_dir"`
+	StaticDir    string `json:"static_dir"`
+	TemplateDir  string `json:"template_dir"`
+	ExamplesDir  string `json:"examples_dir"`
+	PostsPerPage int    `json:"posts_per_page"`
+	Drafts       bool   `json:"drafts"`
+
+	// Force renders every page, ignoring the last build.
+	Force bool `json:"-"`
+	// CheckExamples vets and builds the examples included in posts.
+	CheckExamples bool `json:"-"`
+	// Warnf reports what the build works around, like a missing tool,
+	// the site is still generated. Warnings are dropped when nil.
+	Warnf func(format string, args ...any) `json:"-"`
+}
+
+// defaultConfig is what LoadConfig starts from, before the config file
+// and the flags.
+var defaultConfig = Config{
+	Title:        "cesarFuhr.dev",
+	Description:  "César Fuhr's blog about Go, Nix and software engineering.",
+	Author:       "César Fuhr",
+	Email:        "contact@cesarfuhr.dev",
+	BaseURL:      "https://cesarfuhr.dev",
+	Image:        "/images/cesar_gopher.png",
+	SourceDir:    "content",
+	OutputDir:    "cmd/blog/public",
+	StaticDir:    "static",
+	ExamplesDir:  "cmd/blog/examples",
+	PostsPerPage: 5,
+}
+
+// LoadConfig parses the generator command line, reading the config file
+// when one is given.
+func LoadConfig(args []string) (Config, error) {
+	cfg := defaultConfig
+	var flagCfg Config
+
+	fs := flag.NewFlagSet("gen", flag.ContinueOnError)
+	configFile := fs.String("config", "", "site config file (JSON)")
+	fs.StringVar(&flagCfg.Title, "title", "", "site title")
+	fs.StringVar(&flagCfg.Author, "author", "", "site author")
+	fs.StringVar(&flagCfg.BaseURL, "base-url", "", "absolute site URL, used in feeds and canonical links")
+	fs.StringVar(&flagCfg.SourceDir, "source", "", "markdown content directory")
+	fs.StringVar(&flagCfg.OutputDir, "output", "", "directory the site is written to, replaced on every run")
+	fs.StringVar(&flagCfg.StaticDir, "static", "", "directory of assets copied as they are into the site")
+	fs.StringVar(&flagCfg.TemplateDir, "templates", "", "directory overriding the built in templates")
+	fs.StringVar(&flagCfg.ExamplesDir, "examples", "", "directory of the code included in posts")
+	fs.IntVar(&flagCfg.PostsPerPage, "posts-per-page", 0, "number of posts listed in each index page")
+	fs.BoolVar(&flagCfg.Drafts, "drafts", false, "include drafts and future dated posts, for local preview")
+	fs.BoolVar(&flagCfg.Force, "force", false, "render every page, even the ones unchanged since the last build")
+	fs.BoolVar(&flagCfg.CheckExamples, "check-examples", false, "vet and build the example code included in posts, offline")
+
+	if err := fs.Parse(args); err != nil {
+		return cfg, err
+	}
+	if fs.NArg() > 0 {
+		return cfg, fmt.Errorf("unexpected arguments: %v", fs.Args())
+	}
+
+	if *configFile != "" {
+		b, err := os.ReadFile(*configFile)
+		if err != nil {
+			return cfg, err
+		}
+
+		dec := json.NewDecoder(bytes.NewReader(b))
+		dec.DisallowUnknownFields()
+		if err := dec.Decode(&cfg); err != nil {
+			return cfg, fmt.Errorf("%s: %w", *configFile, err)
+		}
+
+		dir := filepath.Dir(*configFile)
+		for _, path := range []*string{&cfg.SourceDir, &cfg.OutputDir, &cfg.StaticDir, &cfg.TemplateDir, &cfg.ExamplesDir} {
+			if *path != "" && !filepath.IsAbs(*path) {
+				*path = filepath.Join(dir, *path)
+			}
+		}
+	}
+
+	fs.Visit(func(f *flag.Flag) {
+		switch f.Name {
+		case "title":
+			cfg.Title = flagCfg.Title
+		case "author":
+			cfg.Author = flagCfg.Author
+		case "base-url":
+			cfg.BaseURL = flagCfg.BaseURL
+		case "source":
+			cfg.SourceDir = flagCfg.SourceDir
+		case "output":
+			cfg.OutputDir = flagCfg.OutputDir
+		case "static":
+			cfg.StaticDir = flagCfg.StaticDir
+		case "templates":
+			cfg.TemplateDir = flagCfg.TemplateDir
+		case "examples":
+			cfg.ExamplesDir = flagCfg.ExamplesDir
+		case "posts-per-page":
+			cfg.PostsPerPage = flagCfg.PostsPerPage
+		case "drafts":
+			cfg.Drafts = flagCfg.Drafts
+		case "force":
+			cfg.Force = flagCfg.Force
+		case "check-examples":
+			cfg.CheckExamples = flagCfg.CheckExamples
+		}
+	})
+
+	cfg.BaseURL = strings.TrimSuffix(cfg.BaseURL, "/")
+
+	return cfg, cfg.validate()
+}
+
+func (c Config) warnf(format string, args ...any) {
+	if c.Warnf != nil {
+		c.Warnf(format, args...)
+	}
+}
+
+func (c Config) validate() error {
+	var errs []error
+	if c.Title == "" {
+		errs = append(errs, errors.New("title is required"))
+	}
+	if c.BaseURL == "" {
+		errs = append(errs, errors.New("base_url is required"))
+	}
+	if c.SourceDir == "" || c.OutputDir == "" {
+		errs = append(errs, errors.New("source_dir and output_dir are required"))
+	}
+	if c.PostsPerPage < 1 {
+		errs = append(errs, errors.New("posts_per_page must be at least 1"))
+	}
+	return errors.Join(errs...)
+}
@@ -0,0 +1,78 @@
+package gen
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// buildError is a problem found while generating the site, pointing to
+// the file, and the line when known, that caused it.
+type buildError struct {
+	Path string
+	Line int
+	Err  error
+}
+
+func (e buildError) Error() string {
+	switch {
+	case e.Path == "":
+		return e.Err.Error()
+	case e.Line > 0:
+		return fmt.Sprintf("%s:%d: %v", e.Path, e.Line, e.Err)
+	default:
+		return fmt.Sprintf("%s: %v", e.Path, e.Err)
+	}
+}
+
+func (e buildError) Unwrap() error { return e.Err }
+
+// lineError annotates an error with the line of the file it comes from.
+type lineError struct {
+	Line int
+	Err  error
+}
+
+func (e lineError) Error() string { return fmt.Sprintf("line %d: %v", e.Line, e.Err) }
+
+func (e lineError) Unwrap() error { return e.Err }
+
+// problems collects every error of a build, so a broken file does not
+// hide the problems of the others.
+type problems []buildError
+
+// add records err as caused by path, if err is not nil. Any lineError
+// in err sets the line of the problem.
+func (p *problems) add(path string, err error) {
+	if err == nil {
+		return
+	}
+
+	be := buildError{Path: path, Err: err}
+	if errors.As(err, &be) {
+		// Already knows where it comes from.
+		*p = append(*p, be)
+		return
+	}
+
+	var le lineError
+	if errors.As(err, &le) {
+		be.Line, be.Err = le.Line, le.Err
+	}
+	*p = append(*p, be)
+}
+
+func (p problems) Error() string {
+	plural := "s"
+	if len(p) == 1 {
+		plural = ""
+	}
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "%d problem%s found:", len(p), plural)
+	for _, problem := range p {
+		b.WriteString("\n\t")
+		b.WriteString(problem.Error())
+	}
+	return b.String()
+}
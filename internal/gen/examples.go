@@ -0,0 +1,89 @@
+package gen
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+)
+
+// checkExamples vets and builds the Go packages posts include code from,
+// so examples that stopped compiling are reported on the posts using them.
+//
+// It runs offline, examples with dependencies vendor them, so the check
+// passes on a machine with an empty module cache.
+func checkExamples(probs *problems, posts []page) {
+	checked := map[string]error{}
+	for _, post := range posts {
+		for _, include := range post.Includes {
+			if filepath.Ext(include) != ".go" {
+				continue
+			}
+
+			dir := filepath.Dir(include)
+			err, ok := checked[dir]
+			if !ok {
+				err = checkExample(dir)
+				checked[dir] = err
+			}
+			if err != nil {
+				probs.add(post.Source, fmt.Errorf("broken example %s: %w", dir, err))
+			}
+		}
+	}
+}
+
+// checkExample runs go vet and go build on the package in dir, from the
+// module it belongs to.
+func checkExample(dir string) error {
+	abs, err := filepath.Abs(dir)
+	if err != nil {
+		return err
+	}
+	root, err := moduleRoot(abs)
+	if err != nil {
+		return err
+	}
+	pkg, err := filepath.Rel(root, abs)
+	if err != nil {
+		return err
+	}
+	pkg = "./" + filepath.ToSlash(pkg)
+
+	env := append(os.Environ(), "GOPROXY=off", "GOTOOLCHAIN=local")
+	if _, err := os.Stat(filepath.Join(root, "vendor", "modules.txt")); err == nil {
+		// Overriding a -mod=mod the environment may have.
+		env = append(env, "GOFLAGS=-mod=vendor")
+	}
+
+	for _, args := range [][]string{
+		{"vet", pkg},
+		{"build", "-o", os.DevNull, pkg},
+	} {
+		cmd := exec.Command("go", args...)
+		cmd.Dir = root
+		cmd.Env = env
+		if out, err := cmd.CombinedOutput(); err != nil {
+			// Indented to read as part of the problem.
+			msg := strings.ReplaceAll(strings.TrimSpace(string(out)), "\n", "\n\t\t")
+			return fmt.Errorf("go %s: %v\n\t\t%s", args[0], err, msg)
+		}
+	}
+	return nil
+}
+
+// moduleRoot finds the directory of the go.mod governing dir.
+func moduleRoot(dir string) (string, error) {
+	for {
+		if _, err := os.Stat(filepath.Join(dir, "go.mod")); err == nil {
+			return dir, nil
+		}
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return "", errors.New("not in a Go module")
+		}
+		dir = parent
+	}
+}
@@ -0,0 +1,38 @@
+//go:build amd64 || arm64
+
+package gen
+
+import (
+	"os"
+	"syscall"
+	"unsafe"
+)
+
+// From linux/fcntl.h and linux/fs.h, syscall lacks them.
+const (
+	atFDCWD        = -0x64
+	renameExchange = 1 << 1
+)
+
+// exchange swaps the directories at a and b in a single step, with
+// renameat2(2). Both have to exist, and the file system has to support it.
+func exchange(a, b string) error {
+	pa, err := syscall.BytePtrFromString(a)
+	if err != nil {
+		return err
+	}
+	pb, err := syscall.BytePtrFromString(b)
+	if err != nil {
+		return err
+	}
+
+	cwd := atFDCWD
+	_, _, errno := syscall.Syscall6(sysRenameat2,
+		uintptr(cwd), uintptr(unsafe.Pointer(pa)),
+		uintptr(cwd), uintptr(unsafe.Pointer(pb)),
+		renameExchange, 0)
+	if errno != 0 {
+		return &os.LinkError{Op: "renameat2", Old: a, New: b, Err: errno}
+	}
+	return nil
+}
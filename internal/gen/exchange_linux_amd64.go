@@ -0,0 +1,4 @@
+package gen
+
+// sysRenameat2 is the renameat2 system call, missing from syscall on amd64.
+const sysRenameat2 = 316
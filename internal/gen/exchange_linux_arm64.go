@@ -0,0 +1,5 @@
+package gen
+
+import "syscall"
+
+const sysRenameat2 = syscall.SYS_RENAMEAT2
@@ -0,0 +1,11 @@
+//go:build !linux || !(amd64 || arm64)
+
+package gen
+
+import "errors"
+
+// exchange is only implemented on Linux, publish falls back to renames
+// elsewhere.
+func exchange(a, b string) error {
+	return errors.ErrUnsupported
+}
@@ -0,0 +1,198 @@
+package gen
+
+import (
+	"bytes"
+	"encoding/xml"
+	"regexp"
+	"time"
+)
+
+const (
+	rssFile  = "cesarfuhr.rss"
+	atomFile = "cesarfuhr.atom"
+)
+
+// RSS 2.0, https://www.rssboard.org/rss-specification.
+type rss struct {
+	XMLName   xml.Name   `xml:"rss"`
+	Version   string     `xml:"version,attr"`
+	AtomNS    string     `xml:"xmlns:atom,attr"`
+	ContentNS string     `xml:"xmlns:content,attr"`
+	Channel   rssChannel `xml:"channel"`
+}
+
+type rssChannel struct {
+	Title         string    `xml:"title"`
+	Link          string    `xml:"link"`
+	Description   string    `xml:"description"`
+	Language      string    `xml:"language"`
+	LastBuildDate string    `xml:"lastBuildDate"`
+	AtomLink      atomLink  `xml:"atom:link"`
+	Items         []rssItem `xml:"item"`
+}
+
+type rssItem struct {
+	Title       string   `xml:"title"`
+	Link        string   `xml:"link"`
+	GUID        rssGUID  `xml:"guid"`
+	PubDate     string   `xml:"pubDate"`
+	Description string   `xml:"description,omitempty"`
+	Categories  []string `xml:"category"`
+	Content     cdata    `xml:"content:encoded"`
+}
+
+type rssGUID struct {
+	IsPermaLink bool   `xml:"isPermaLink,attr"`
+	Value       string `xml:",chardata"`
+}
+
+type cdata struct {
+	Value string `xml:",cdata"`
+}
+
+// Atom 1.0, RFC 4287.
+type atomFeed struct {
+	XMLName xml.Name    `xml:"http://www.w3.org/2005/Atom feed"`
+	Title   string      `xml:"title"`
+	ID      string      `xml:"id"`
+	Updated string      `xml:"updated"`
+	Author  atomAuthor  `xml:"author"`
+	Links   []atomLink  `xml:"link"`
+	Entries []atomEntry `xml:"entry"`
+}
+
+type atomAuthor struct {
+	Name  string `xml:"name"`
+	Email string `xml:"email,omitempty"`
+}
+
+type atomLink struct {
+	Href string `xml:"href,attr"`
+	Rel  string `xml:"rel,attr,omitempty"`
+	Type string `xml:"type,attr,omitempty"`
+}
+
+type atomEntry struct {
+	Title      string         `xml:"title"`
+	ID         string         `xml:"id"`
+	Link       atomLink       `xml:"link"`
+	Published  string         `xml:"published"`
+	Updated    string         `xml:"updated"`
+	Summary    string         `xml:"summary,omitempty"`
+	Categories []atomCategory `xml:"category"`
+	Content    atomContent    `xml:"content"`
+}
+
+type atomCategory struct {
+	Term string `xml:"term,attr"`
+}
+
+type atomContent struct {
+	Type  string `xml:"type,attr"`
+	Value string `xml:",chardata"`
+}
+
+// rssFeed builds the RSS 2.0 feed, newest posts first. The pages must
+// be sorted by date and have their Content already rendered.
+func (b *builder) rssFeed(pages []page) ([]byte, error) {
+	channel := rssChannel{
+		Title:         b.site.Title,
+		Link:          b.site.BaseURL + "/",
+		Description:   b.site.Description,
+		Language:      "en",
+		LastBuildDate: lastUpdate(pages).Format(time.RFC1123Z),
+		AtomLink:      atomLink{Href: b.site.BaseURL + "/" + rssFile, Rel: "self", Type: "application/rss+xml"},
+	}
+
+	for i := len(pages) - 1; i >= 0; i-- {
+		p := pages[i]
+		link := b.url(p)
+		channel.Items = append(channel.Items, rssItem{
+			Title:       p.Title,
+			Link:        link,
+			GUID:        rssGUID{IsPermaLink: true, Value: link},
+			PubDate:     p.Date.Format(time.RFC1123Z),
+			Description: p.Description,
+			Categories:  p.Tags,
+			Content:     cdata{Value: b.feedContent(p)},
+		})
+	}
+
+	return marshalXML(rss{
+		Version:   "2.0",
+		AtomNS:    "http://www.w3.org/2005/Atom",
+		ContentNS: "http://purl.org/rss/1.0/modules/content/",
+		Channel:   channel,
+	})
+}
+
+// atom builds the Atom 1.0 feed, newest posts first. The pages must
+// be sorted by date and have their Content already rendered.
+func (b *builder) atom(pages []page) ([]byte, error) {
+	feed := atomFeed{
+		Title:   b.site.Title,
+		ID:      b.site.BaseURL + "/",
+		Updated: lastUpdate(pages).Format(time.RFC3339),
+		Author:  atomAuthor{Name: b.site.Author, Email: b.site.Email},
+		Links: []atomLink{
+			{Href: b.site.BaseURL + "/" + atomFile, Rel: "self", Type: "application/atom+xml"},
+			{Href: b.site.BaseURL + "/", Rel: "alternate", Type: "text/html"},
+		},
+	}
+
+	for i := len(pages) - 1; i >= 0; i-- {
+		p := pages[i]
+		entry := atomEntry{
+			Title:     p.Title,
+			ID:        b.url(p),
+			Link:      atomLink{Href: b.url(p), Rel: "alternate", Type: "text/html"},
+			Published: p.Date.Format(time.RFC3339),
+			Updated:   p.Date.Format(time.RFC3339),
+			Summary:   p.Description,
+			Content:   atomContent{Type: "html", Value: b.feedContent(p)},
+		}
+		for _, tag := range p.Tags {
+			entry.Categories = append(entry.Categories, atomCategory{Term: tag})
+		}
+		feed.Entries = append(feed.Entries, entry)
+	}
+
+	return marshalXML(feed)
+}
+
+// rootRelative matches the links and images of rendered content pointing
+// to the site root, protocol relative ones left out.
+var rootRelative = regexp.MustCompile(`\b(href|src)="(/[^/"][^"]*|/)"`)
+
+// feedContent is the content of p with its links made absolute, readers
+// resolve relative ones against the feed, if at all.
+func (b *builder) feedContent(p page) string {
+	return rootRelative.ReplaceAllStringFunc(string(p.Content), func(attr string) string {
+		m := rootRelative.FindStringSubmatch(attr)
+		return m[1] + `="` + b.site.BaseURL + m[2] + `"`
+	})
+}
+
+// lastUpdate is the date of the most recent page, so the feeds only
+// change when the content does.
+func lastUpdate(pages []page) time.Time {
+	if len(pages) == 0 {
+		return time.Date(2021, 12, 20, 0, 0, 0, 0, time.UTC)
+	}
+	return pages[len(pages)-1].Date
+}
+
+// marshalXML encodes v as an indented XML document.
+func marshalXML(v any) ([]byte, error) {
+	var out bytes.Buffer
+	out.WriteString(xml.Header)
+
+	enc := xml.NewEncoder(&out)
+	enc.Indent("", "  ")
+	if err := enc.Encode(v); err != nil {
+		return nil, err
+	}
+	out.WriteString("\n")
+
+	return out.Bytes(), nil
+}
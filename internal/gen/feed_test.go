@@ -0,0 +1,283 @@
+package gen
+
+import (
+	"bytes"
+	"encoding/xml"
+	"flag"
+	"io"
+	"net/url"
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"testing"
+	"time"
+)
+
+var update = flag.Bool("update", false, "rewrite the golden files with the current output")
+
+// feedPosts are the posts of the feed tests, oldest first like the ones
+// handed to the feeds by generate.
+func feedPosts() []page {
+	return []page{
+		{
+			Title:       "First post",
+			Dest:        "first.html",
+			Path:        "/blog/first.html",
+			Date:        time.Date(2021, 12, 20, 0, 0, 0, 0, time.UTC),
+			Description: "Where it all starts.",
+			Tags:        []string{"go", "http"},
+			Markdown:    []byte("Hello, see the [about page](/about.html).\n"),
+			Article:     true,
+		},
+		{
+			Title:    "Pictures & <code>",
+			Dest:     "pictures.html",
+			Path:     "/blog/pictures.html",
+			Date:     time.Date(2022, 2, 7, 10, 30, 0, 0, time.UTC),
+			Tags:     []string{"c/c++"},
+			Markdown: []byte("![A diagram](/images/diagram.png)\n\n```go\nfmt.Println(\"<a href=\\\"/x\\\">\")\n```\n"),
+			Article:  true,
+		},
+		{
+			Title:       "Elsewhere",
+			Dest:        "elsewhere.html",
+			Path:        "/blog/elsewhere.html",
+			Date:        time.Date(2024, 5, 20, 0, 0, 0, 0, time.UTC),
+			Description: "Links out.",
+			Markdown:    []byte("Read [the spec](https://www.rfc-editor.org/rfc/rfc4287) and [the first post](/blog/first.html#intro).\n"),
+			Article:     true,
+		},
+	}
+}
+
+// feedBuilder renders the feed posts for the test site.
+func feedBuilder() (*builder, []page) {
+	b := &builder{
+		site: Config{
+			Title:       "Test site",
+			Description: "A site for the tests.",
+			Author:      "Jane Doe",
+			Email:       "jane@example.com",
+			BaseURL:     "https://example.com",
+		},
+		thisBuild:   newManifest(),
+		lastBuild:   newManifest(),
+		assets:      map[string]string{"/images/diagram.png": "/images/diagram.0123abcd.png"},
+		pageSources: map[string]string{},
+	}
+	posts := feedPosts()
+	for i := range posts {
+		b.render(&posts[i])
+	}
+	return b, posts
+}
+
+func TestFeeds(t *testing.T) {
+	b, posts := feedBuilder()
+	tests := []struct {
+		golden string
+		build  func([]page) ([]byte, error)
+	}{
+		{golden: "feed.rss.golden", build: b.rssFeed},
+		{golden: "feed.atom.golden", build: b.atom},
+	}
+	for _, test := range tests {
+		t.Run(test.golden, func(t *testing.T) {
+			got, err := test.build(posts)
+			if err != nil {
+				t.Fatal(err)
+			}
+			checkWellFormed(t, got)
+
+			golden := filepath.Join("testdata", test.golden)
+			if *update {
+				if err := os.WriteFile(golden, got, 0644); err != nil {
+					t.Fatal(err)
+				}
+				return
+			}
+
+			want, err := os.ReadFile(golden)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !bytes.Equal(got, want) {
+				t.Errorf("feed differs from %s, run go test -update to accept it\ngot:\n%s", golden, got)
+			}
+		})
+	}
+}
+
+// checkWellFormed fails the test if doc is not well formed XML.
+func checkWellFormed(t *testing.T, doc []byte) {
+	t.Helper()
+	dec := xml.NewDecoder(bytes.NewReader(doc))
+	for {
+		_, err := dec.Token()
+		if err == io.EOF {
+			return
+		}
+		if err != nil {
+			t.Fatalf("feed is not well formed XML: %v", err)
+		}
+	}
+}
+
+// The elements the specs require, or the readers rely on, decoded apart
+// from the types writing them.
+type (
+	specRSS struct {
+		Version string `xml:"version,attr"`
+		Channel struct {
+			Title string `xml:"title"`
+			// The atom:link of the channel matches too.
+			Links       []string `xml:"link"`
+			Description string   `xml:"description"`
+			Items       []struct {
+				Title       string   `xml:"title"`
+				Link        string   `xml:"link"`
+				GUID        string   `xml:"guid"`
+				PubDate     string   `xml:"pubDate"`
+				Description *string  `xml:"description"`
+				Categories  []string `xml:"category"`
+				Content     string   `xml:"http://purl.org/rss/1.0/modules/content/ encoded"`
+			} `xml:"item"`
+		} `xml:"channel"`
+	}
+
+	specLink struct {
+		Href string `xml:"href,attr"`
+		Rel  string `xml:"rel,attr"`
+	}
+
+	specAtom struct {
+		XMLName xml.Name   `xml:"http://www.w3.org/2005/Atom feed"`
+		ID      string     `xml:"id"`
+		Title   string     `xml:"title"`
+		Updated string     `xml:"updated"`
+		Author  string     `xml:"author>name"`
+		Links   []specLink `xml:"link"`
+		Entries []struct {
+			ID        string     `xml:"id"`
+			Title     string     `xml:"title"`
+			Updated   string     `xml:"updated"`
+			Published string     `xml:"published"`
+			Links     []specLink `xml:"link"`
+			Summary   *string    `xml:"summary"`
+			Content   struct {
+				Type  string `xml:"type,attr"`
+				Value string `xml:",chardata"`
+			} `xml:"content"`
+		} `xml:"entry"`
+	}
+)
+
+func TestRSSElements(t *testing.T) {
+	b, posts := feedBuilder()
+	doc, err := b.rssFeed(posts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var feed specRSS
+	if err := xml.Unmarshal(doc, &feed); err != nil {
+		t.Fatal(err)
+	}
+
+	if feed.Version != "2.0" {
+		t.Errorf("got version %q, want 2.0", feed.Version)
+	}
+	channel := feed.Channel
+	if channel.Title != "Test site" || !slices.Contains(channel.Links, "https://example.com/") || channel.Description != "A site for the tests." {
+		t.Errorf("got channel %q, %q, %q", channel.Title, channel.Links, channel.Description)
+	}
+
+	want := []struct{ title, link, date, description string }{
+		{"Elsewhere", "https://example.com/blog/elsewhere.html", "Mon, 20 May 2024 00:00:00 +0000", "Links out."},
+		{"Pictures & <code>", "https://example.com/blog/pictures.html", "Mon, 07 Feb 2022 10:30:00 +0000", ""},
+		{"First post", "https://example.com/blog/first.html", "Mon, 20 Dec 2021 00:00:00 +0000", "Where it all starts."},
+	}
+	if len(channel.Items) != len(want) {
+		t.Fatalf("got %d items, want %d", len(channel.Items), len(want))
+	}
+	for i, item := range channel.Items {
+		w := want[i]
+		if item.Title != w.title || item.Link != w.link || item.GUID != w.link || item.PubDate != w.date {
+			t.Errorf("item %d is %q, %q, %q, %q, want %q, %q, %q, %q", i, item.Title, item.Link, item.GUID, item.PubDate, w.title, w.link, w.link, w.date)
+		}
+		switch {
+		case w.description == "" && item.Description != nil:
+			t.Errorf("item %q has an empty description, want none", item.Title)
+		case w.description != "" && (item.Description == nil || *item.Description != w.description):
+			t.Errorf("item %q has description %v, want %q", item.Title, item.Description, w.description)
+		}
+		checkAbsoluteLinks(t, item.Title, item.Content)
+	}
+	if got := channel.Items[2].Categories; !slices.Equal(got, []string{"go", "http"}) {
+		t.Errorf("got categories %q", got)
+	}
+}
+
+func TestAtomElements(t *testing.T) {
+	b, posts := feedBuilder()
+	doc, err := b.atom(posts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var feed specAtom
+	if err := xml.Unmarshal(doc, &feed); err != nil {
+		t.Fatal(err)
+	}
+
+	if feed.ID != "https://example.com/" || feed.Title != "Test site" || feed.Updated != "2024-05-20T00:00:00Z" {
+		t.Errorf("got feed %q, %q, %q", feed.ID, feed.Title, feed.Updated)
+	}
+	// Entries have no author of their own, the feed one is required.
+	if feed.Author != "Jane Doe" {
+		t.Errorf("got author %q", feed.Author)
+	}
+	if !slices.Contains(feed.Links, specLink{Href: "https://example.com/cesarfuhr.atom", Rel: "self"}) {
+		t.Errorf("got links %v, want the self link", feed.Links)
+	}
+
+	want := []struct{ title, link, updated, summary string }{
+		{"Elsewhere", "https://example.com/blog/elsewhere.html", "2024-05-20T00:00:00Z", "Links out."},
+		{"Pictures & <code>", "https://example.com/blog/pictures.html", "2022-02-07T10:30:00Z", ""},
+		{"First post", "https://example.com/blog/first.html", "2021-12-20T00:00:00Z", "Where it all starts."},
+	}
+	if len(feed.Entries) != len(want) {
+		t.Fatalf("got %d entries, want %d", len(feed.Entries), len(want))
+	}
+	for i, entry := range feed.Entries {
+		w := want[i]
+		if entry.ID != w.link || entry.Title != w.title || entry.Updated != w.updated || entry.Published != w.updated {
+			t.Errorf("entry %d is %q, %q, %q, %q, want %q, %q, %q", i, entry.ID, entry.Title, entry.Updated, entry.Published, w.link, w.title, w.updated)
+		}
+		if !slices.Contains(entry.Links, specLink{Href: w.link, Rel: "alternate"}) {
+			t.Errorf("entry %q has links %v, want the alternate one", entry.Title, entry.Links)
+		}
+		switch {
+		case w.summary == "" && entry.Summary != nil:
+			t.Errorf("entry %q has an empty summary, want none", entry.Title)
+		case w.summary != "" && (entry.Summary == nil || *entry.Summary != w.summary):
+			t.Errorf("entry %q has summary %v, want %q", entry.Title, entry.Summary, w.summary)
+		}
+		if entry.Content.Type != "html" {
+			t.Errorf("entry %q has content of type %q, want html", entry.Title, entry.Content.Type)
+		}
+		checkAbsoluteLinks(t, entry.Title, entry.Content.Value)
+	}
+}
+
+// checkAbsoluteLinks fails the test if the links and images of content
+// are not absolute URLs.
+func checkAbsoluteLinks(t *testing.T, title, content string) {
+	t.Helper()
+	links := regexp.MustCompile(`\b(?:href|src)="([^"]*)"`).FindAllStringSubmatch(content, -1)
+	for _, link := range links {
+		u, err := url.Parse(link[1])
+		if err != nil || !u.IsAbs() || u.Host == "" {
+			t.Errorf("%q links to %q, want an absolute URL", title, link[1])
+		}
+	}
+}
@@ -0,0 +1,229 @@
+package gen
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const frontMatterDelim = "---"
+
+// frontMatter is the metadata block found at the top of a content file.
+//
+// It is the subset of YAML the posts need, line oriented: each line is a
+// "key: value" pair, the value being the rest of the line after the first
+// colon. Values can be quoted, double quotes allowing Go escapes, single
+// quotes none. Values with a ": " of their own are read fine unquoted too,
+// but need quotes to stay valid YAML. Lists are written inline ([a, b]) or
+// as "- item" lines under their key, only tags are lists. Blank lines and
+// lines starting with # are ignored, unknown keys and keys without a
+// value are errors:
+//
+//	---
+//	title: How hard could it be to code a simple HTTPS server with Go?
+//	date: 2021-12-20
+//	description: "The short answer: actually pretty easy..."
+//	tags: [go, http]
+//	draft: false
+//	image: /images/cesar_gopher.png
+//	toc: false
+//	---
+type frontMatter struct {
+	Title       string
+	Date        time.Time
+	Description string
+	Tags        []string
+	Draft       bool
+	Image       string
+	// NoTOC opts out of the table of contents.
+	NoTOC bool
+}
+
+// parseFrontMatter splits the front matter block from the markdown body.
+// If md does not start with a front matter block, found is false and body
+// is md untouched.
+func parseFrontMatter(md []byte) (fm frontMatter, body []byte, found bool, err error) {
+	// Files saved on Windows end their lines with \r\n.
+	newline := "\n"
+	rest, ok := bytes.CutPrefix(md, []byte(frontMatterDelim+newline))
+	if !ok {
+		newline = "\r\n"
+		rest, ok = bytes.CutPrefix(md, []byte(frontMatterDelim+newline))
+	}
+	if !ok {
+		return fm, md, false, nil
+	}
+
+	var block []byte
+	switch {
+	case bytes.HasPrefix(rest, []byte(frontMatterDelim+newline)):
+		// An empty block.
+		body = rest[len(frontMatterDelim+newline):]
+	case bytes.Equal(rest, []byte(frontMatterDelim)):
+	default:
+		block, body, ok = bytes.Cut(rest, []byte(newline+frontMatterDelim+newline))
+		if !ok {
+			// The closing delimiter may be the last line of the file.
+			block, ok = bytes.CutSuffix(rest, []byte(newline+frontMatterDelim))
+			if !ok {
+				return fm, md, false, lineError{Line: 1, Err: fmt.Errorf("front matter: missing closing %q", frontMatterDelim)}
+			}
+			body = nil
+		}
+	}
+
+	var listKey string
+	scanner := bufio.NewScanner(bytes.NewReader(block))
+	for line := 1; scanner.Scan(); line++ {
+		text := strings.TrimSpace(scanner.Text())
+		if text == "" || strings.HasPrefix(text, "#") {
+			continue
+		}
+
+		// Continuation of a block list.
+		if item, ok := strings.CutPrefix(text, "- "); ok && listKey != "" {
+			item, err := unquote(item)
+			if err == nil {
+				err = fm.set(listKey, []string{item})
+			}
+			if err != nil {
+				return fm, md, true, lineError{Line: line + 1, Err: fmt.Errorf("front matter: %w", err)}
+			}
+			continue
+		}
+		listKey = ""
+
+		key, value, ok := strings.Cut(text, ":")
+		if !ok {
+			return fm, md, true, lineError{Line: line + 1, Err: errors.New(`front matter: expected "key: value"`)}
+		}
+		key = strings.ToLower(strings.TrimSpace(key))
+		value = strings.TrimSpace(value)
+
+		if value == "" && key == "tags" {
+			// The start of a block list.
+			listKey = key
+			continue
+		}
+
+		if err := fm.set(key, value); err != nil {
+			return fm, md, true, lineError{Line: line + 1, Err: fmt.Errorf("front matter: %w", err)}
+		}
+	}
+
+	return fm, body, true, scanner.Err()
+}
+
+// frontMatterKeys are the keys set knows about.
+var frontMatterKeys = []string{"title", "date", "description", "tags", "draft", "image", "toc"}
+
+// set assigns value to the field named by key. Values are either a raw
+// string or a list of already unquoted items.
+func (fm *frontMatter) set(key string, value any) (err error) {
+	if items, ok := value.([]string); ok {
+		if key != "tags" {
+			return fmt.Errorf("%q is not a list", key)
+		}
+		fm.Tags = append(fm.Tags, items...)
+		return nil
+	}
+
+	raw := value.(string)
+	if raw == "" && slices.Contains(frontMatterKeys, key) {
+		return fmt.Errorf("%q has no value", key)
+	}
+
+	switch key {
+	case "title":
+		fm.Title, err = unquote(raw)
+	case "description":
+		fm.Description, err = unquote(raw)
+	case "image":
+		fm.Image, err = unquote(raw)
+	case "date":
+		s, err := unquote(raw)
+		if err != nil {
+			return err
+		}
+		fm.Date, err = parseDate(s)
+		if err != nil {
+			return err
+		}
+	case "draft":
+		draft, err := strconv.ParseBool(raw)
+		if err != nil {
+			return fmt.Errorf("draft: %w", err)
+		}
+		fm.Draft = draft
+	case "toc":
+		toc, err := strconv.ParseBool(raw)
+		if err != nil {
+			return fmt.Errorf("toc: %w", err)
+		}
+		fm.NoTOC = !toc
+	case "tags":
+		tags, err := parseList(raw)
+		if err != nil {
+			return err
+		}
+		fm.Tags = append(fm.Tags, tags...)
+	default:
+		return fmt.Errorf("unknown key %q", key)
+	}
+
+	return err
+}
+
+var dateLayouts = []string{
+	"2006-01-02",
+	"2006-01-02 15:04",
+	time.RFC3339,
+}
+
+func parseDate(s string) (time.Time, error) {
+	for _, layout := range dateLayouts {
+		if date, err := time.Parse(layout, s); err == nil {
+			return date, nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("unknown date format %q", s)
+}
+
+// parseList parses an inline list, "[a, b]" or just "a, b". Items can not
+// have commas, quoted or not.
+func parseList(s string) ([]string, error) {
+	s = strings.TrimSuffix(strings.TrimPrefix(s, "["), "]")
+
+	var items []string
+	for item := range strings.SplitSeq(s, ",") {
+		item, err := unquote(strings.TrimSpace(item))
+		if err != nil {
+			return nil, err
+		}
+		if item != "" {
+			items = append(items, item)
+		}
+	}
+	return items, nil
+}
+
+// unquote removes the quotes around s, if any. Double quoted strings have
+// the escapes of Go strings, single quoted ones are taken as they are.
+func unquote(s string) (string, error) {
+	switch {
+	case len(s) >= 2 && s[0] == '"' && s[len(s)-1] == '"':
+		u, err := strconv.Unquote(s)
+		if err != nil {
+			return "", fmt.Errorf("invalid quoted string %s", s)
+		}
+		return u, nil
+	case len(s) >= 2 && s[0] == '\'' && s[len(s)-1] == '\'':
+		return s[1 : len(s)-1], nil
+	}
+	return s, nil
+}
@@ -0,0 +1,195 @@
+package gen
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestParseFrontMatter(t *testing.T) {
+	tests := []struct {
+		name  string
+		md    string
+		want  frontMatter
+		body  string
+		found bool
+		err   string
+	}{
+		{
+			name:  "no front matter",
+			md:    "# Title\n",
+			body:  "# Title\n",
+			found: false,
+		},
+		{
+			name: "every key",
+			md: "---\n" +
+				"title: Hello\n" +
+				"date: 2021-12-20\n" +
+				"description: The short answer: actually pretty easy...\n" +
+				"tags: [go, http]\n" +
+				"draft: true\n" +
+				"image: /images/a.png\n" +
+				"toc: false\n" +
+				"---\n" +
+				"Body\n",
+			want: frontMatter{
+				Title:       "Hello",
+				Date:        time.Date(2021, 12, 20, 0, 0, 0, 0, time.UTC),
+				Description: "The short answer: actually pretty easy...",
+				Tags:        []string{"go", "http"},
+				Draft:       true,
+				Image:       "/images/a.png",
+				NoTOC:       true,
+			},
+			body:  "Body\n",
+			found: true,
+		},
+		{
+			name:  "crlf",
+			md:    "---\r\ntitle: Hello\r\ntags: [go]\r\n---\r\nBody\r\n",
+			want:  frontMatter{Title: "Hello", Tags: []string{"go"}},
+			body:  "Body\r\n",
+			found: true,
+		},
+		{
+			name:  "closing delimiter ending the file",
+			md:    "---\ntitle: Hello\n---",
+			want:  frontMatter{Title: "Hello"},
+			found: true,
+		},
+		{
+			name:  "missing closing delimiter",
+			md:    "---\ntitle: Hello\n\nBody\n",
+			body:  "---\ntitle: Hello\n\nBody\n",
+			found: false,
+			err:   `line 1: front matter: missing closing "---"`,
+		},
+		{
+			name:  "empty block",
+			md:    "---\n---\nBody\n",
+			body:  "Body\n",
+			found: true,
+		},
+		{
+			name:  "empty block ending the file",
+			md:    "---\n---",
+			found: true,
+		},
+		{
+			name:  "block list",
+			md:    "---\ntags:\n  - go\n  - \"c/c++\"\ntitle: Hello\n---\n",
+			want:  frontMatter{Title: "Hello", Tags: []string{"go", "c/c++"}},
+			found: true,
+		},
+		{
+			name:  "inline list without brackets",
+			md:    "---\ntags: go, 'http', \"tls\"\n---\n",
+			want:  frontMatter{Tags: []string{"go", "http", "tls"}},
+			found: true,
+		},
+		{
+			name:  "quotes",
+			md:    "---\ntitle: \"Say \\\"hi\\\"\\n\"\ndescription: 'it''s'\n---\n",
+			want:  frontMatter{Title: "Say \"hi\"\n", Description: "it''s"},
+			found: true,
+		},
+		{
+			name:  "comments and blank lines",
+			md:    "---\n# A comment\n\ntitle: Hello\n---\n",
+			want:  frontMatter{Title: "Hello"},
+			found: true,
+		},
+		{
+			name:  "date and time",
+			md:    "---\ndate: 2022-02-07 10:30\n---\n",
+			want:  frontMatter{Date: time.Date(2022, 2, 7, 10, 30, 0, 0, time.UTC)},
+			found: true,
+		},
+		{
+			name:  "unknown key",
+			md:    "---\ntitle: Hello\nauthor: Jane\n---\n",
+			body:  "---\ntitle: Hello\nauthor: Jane\n---\n",
+			found: true,
+			err:   `line 3: front matter: unknown key "author"`,
+		},
+		{
+			name:  "not a key value pair",
+			md:    "---\ntitle: Hello\n\njust text\n---\n",
+			body:  "---\ntitle: Hello\n\njust text\n---\n",
+			found: true,
+			err:   `line 4: front matter: expected "key: value"`,
+		},
+		{
+			name:  "list of a scalar key",
+			md:    "---\ntitle:\n  - Hello\n---\n",
+			body:  "---\ntitle:\n  - Hello\n---\n",
+			found: true,
+			err:   `line 2: front matter: "title" has no value`,
+		},
+		{
+			name:  "unknown key without value",
+			md:    "---\ntilte:\n---\n",
+			body:  "---\ntilte:\n---\n",
+			found: true,
+			err:   `line 2: front matter: unknown key "tilte"`,
+		},
+		{
+			name:  "scalar key without value",
+			md:    "---\ntitle: Hello\ndescription:\n---\n",
+			body:  "---\ntitle: Hello\ndescription:\n---\n",
+			found: true,
+			err:   `line 3: front matter: "description" has no value`,
+		},
+		{
+			name:  "empty block list",
+			md:    "---\ntitle: Hello\ntags:\n---\n",
+			want:  frontMatter{Title: "Hello"},
+			found: true,
+		},
+		{
+			name:  "invalid date",
+			md:    "---\r\ntitle: Hello\r\ndate: 20/12/2021\r\n---\r\n",
+			body:  "---\r\ntitle: Hello\r\ndate: 20/12/2021\r\n---\r\n",
+			found: true,
+			err:   `line 3: front matter: unknown date format "20/12/2021"`,
+		},
+		{
+			name:  "invalid bool",
+			md:    "---\ndraft: maybe\n---\n",
+			body:  "---\ndraft: maybe\n---\n",
+			found: true,
+			err:   `line 2: front matter: draft: strconv.ParseBool: parsing "maybe": invalid syntax`,
+		},
+		{
+			name:  "invalid escape",
+			md:    "---\ntitle: \"a\\qb\"\n---\n",
+			body:  "---\ntitle: \"a\\qb\"\n---\n",
+			found: true,
+			err:   `line 2: front matter: invalid quoted string "a\qb"`,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			fm, body, found, err := parseFrontMatter([]byte(test.md))
+			if test.err != "" {
+				if err == nil || err.Error() != test.err {
+					t.Fatalf("got error %v, want %s", err, test.err)
+				}
+			} else {
+				if err != nil {
+					t.Fatal(err)
+				}
+				if !reflect.DeepEqual(fm, test.want) {
+					t.Errorf("got front matter %+v, want %+v", fm, test.want)
+				}
+			}
+			if string(body) != test.body {
+				t.Errorf("got body %q, want %q", body, test.body)
+			}
+			if found != test.found {
+				t.Errorf("got found %v, want %v", found, test.found)
+			}
+		})
+	}
+}
@@ -0,0 +1,839 @@
+// Package gen generates the blog, a static site, from markdown content.
+package gen
+
+import (
+	"bytes"
+	"cmp"
+	"embed"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"html/template"
+	"image"
+	"image/png"
+	"io"
+	"io/fs"
+	"maps"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/gomarkdown/markdown"
+	"github.com/gomarkdown/markdown/ast"
+	"github.com/gomarkdown/markdown/html"
+	"github.com/gomarkdown/markdown/parser"
+	"golang.org/x/text/cases"
+	"golang.org/x/text/language"
+)
+
+// Build generates the site described by cfg into its output folder. The
+// published site is only replaced when everything is generated without
+// problems, otherwise the returned error lists all of them.
+func Build(cfg Config) error {
+	b := &builder{
+		site:        cfg,
+		lastBuild:   newManifest(),
+		thisBuild:   newManifest(),
+		assets:      map[string]string{},
+		pageSources: map[string]string{},
+	}
+
+	staging, err := stage(b.site.OutputDir)
+	if err != nil {
+		return err
+	}
+	defer os.RemoveAll(staging)
+
+	if !b.site.Force {
+		b.lastBuild = loadManifest(b.site.OutputDir)
+	}
+
+	var probs problems
+	b.generate(&probs, staging)
+	if len(probs) == 0 {
+		b.verify(staging, &probs)
+	}
+	if len(probs) == 0 {
+		probs.add("", b.thisBuild.write(staging))
+	}
+	if len(probs) == 0 {
+		probs.add(b.site.OutputDir, publish(staging, b.site.OutputDir))
+	}
+
+	if len(probs) > 0 {
+		return probs
+	}
+	return nil
+}
+
+// builder holds the state of a build, every run of Build has its own.
+type builder struct {
+	site Config
+	// lastBuild is the manifest of the published site, empty if there is
+	// none or a full rebuild was asked for.
+	lastBuild manifest
+	// thisBuild is filled while the site is generated.
+	thisBuild manifest
+	// assets maps the path of the static assets, relative to the site
+	// root, to their fingerprinted path.
+	assets map[string]string
+	// pageSources maps the path of the pages rendered from content to
+	// their source file, so broken links are reported where they can be
+	// fixed.
+	pageSources map[string]string
+
+	pageTemplate, indexTemplate, tagTemplate, tagsTemplate *template.Template
+
+	buf bytes.Buffer
+}
+
+// generate builds the whole site into out, recording every problem found
+// on the way instead of stopping at the first one.
+func (b *builder) generate(probs *problems, out string) {
+	templatesKey, err := b.loadTemplates(b.site.TemplateDir)
+	if err != nil {
+		// Nothing can be rendered without the templates.
+		probs.add("", err)
+		return
+	}
+
+	// Every output depends on the generator itself, the Config and the
+	// templates.
+	configJSON, err := json.Marshal(b.site)
+	if err != nil {
+		probs.add("", err)
+		return
+	}
+	baseKey := hashOf(generatorKey(), string(configJSON), templatesKey)
+
+	dirEntries, err := os.ReadDir(b.site.SourceDir)
+	if err != nil {
+		probs.add(b.site.SourceDir, err)
+		return
+	}
+
+	// Static assets are copied as they are.
+	if b.site.StaticDir != "" {
+		err = os.CopyFS(out, os.DirFS(b.site.StaticDir))
+		if err != nil {
+			probs.add(b.site.StaticDir, err)
+			return
+		}
+	}
+
+	// Pages link to the assets by their fingerprinted names.
+	assetsKey, err := b.fingerprintAssets(out)
+	if err != nil {
+		probs.add(b.site.StaticDir, err)
+		return
+	}
+	baseKey = hashOf(baseKey, assetsKey)
+
+	err = os.MkdirAll(filepath.Join(out, "blog"), 0755)
+	if err != nil {
+		probs.add(out, err)
+		return
+	}
+
+	// Every published page, listed in the sitemap.
+	var published []page
+
+	// Write the about page. Its front matter is optional, only the title,
+	// date and description are used. Without a date the sitemap has no
+	// last modification for it.
+	aboutSource := filepath.Join(b.site.SourceDir, "about.md")
+	sourceBytes, err := os.ReadFile(aboutSource)
+	var aboutMeta frontMatter
+	var aboutBody []byte
+	if err == nil {
+		aboutMeta, aboutBody, _, err = parseFrontMatter(sourceBytes)
+	}
+	if err != nil {
+		probs.add(aboutSource, err)
+	} else {
+		aboutPage := page{
+			Title:       cmp.Or(aboutMeta.Title, "About"),
+			Source:      aboutSource,
+			Path:        "/about.html",
+			Date:        aboutMeta.Date,
+			Description: aboutMeta.Description,
+			Image:       b.site.Image,
+			Markdown:    aboutBody,
+			key:         hashOf(baseKey, b.thisBuild.input(aboutSource, sourceBytes)),
+		}
+		probs.add(aboutSource, b.writePage(out, aboutPage))
+		published = append(published, aboutPage)
+	}
+
+	now := time.Now()
+
+	var blogPosts []page
+	dests := map[string]string{}
+	for _, entry := range dirEntries {
+		sourceFileName := entry.Name()
+		source := filepath.Join(b.site.SourceDir, sourceFileName)
+
+		if entry.IsDir() {
+			// why? why? a directory here?
+			probs.add(source, errors.New("we shouldn't have dir in source folder"))
+			continue
+		}
+
+		if sourceFileName == "about.md" {
+			continue
+		}
+
+		if filepath.Ext(sourceFileName) != ".md" {
+			probs.add(source, errors.New("not a markdown file"))
+			continue
+		}
+
+		sourceBytes, err := os.ReadFile(source)
+		if err != nil {
+			probs.add(source, err)
+			continue
+		}
+
+		meta, body, err := postMeta(sourceFileName, sourceBytes)
+		if err != nil {
+			probs.add(source, err)
+			continue
+		}
+
+		if meta.Title == "" || meta.Date.IsZero() {
+			probs.add(source, errors.New("front matter must have a title and a date"))
+			continue
+		}
+
+		// Tags name their listing page.
+		if i := slices.IndexFunc(meta.Tags, func(tag string) bool { return tagSlug(tag) == "" }); i >= 0 {
+			probs.add(source, fmt.Errorf("tag %q needs a letter or a digit", meta.Tags[i]))
+			continue
+		}
+		// Tags with the same slug are the same tag, the first spelling wins.
+		seen := map[string]bool{}
+		meta.Tags = slices.DeleteFunc(meta.Tags, func(tag string) bool {
+			slug := tagSlug(tag)
+			if seen[slug] {
+				return true
+			}
+			seen[slug] = true
+			return false
+		})
+
+		// Previews link the image as it is, the link check never sees it.
+		if meta.Image != "" && !strings.HasPrefix(meta.Image, "http://") && !strings.HasPrefix(meta.Image, "https://") {
+			image := filepath.Join(out, filepath.FromSlash(strings.TrimPrefix(meta.Image, "/")))
+			if info, err := os.Stat(image); err != nil || info.IsDir() {
+				probs.add(source, fmt.Errorf("image %s is not in the site", meta.Image))
+				continue
+			}
+		}
+
+		// Include lines are counted from the start of the body.
+		bodyLine := bytes.Count(sourceBytes[:len(sourceBytes)-len(body)], []byte("\n"))
+		body, includes, err := b.expandIncludes(body)
+		if err != nil {
+			var le lineError
+			if errors.As(err, &le) {
+				le.Line += bodyLine
+				err = le
+			}
+			probs.add(source, err)
+			continue
+		}
+		key := b.thisBuild.input(source, sourceBytes)
+		for _, include := range includes {
+			key = hashOf(key, b.thisBuild.Inputs[filepath.ToSlash(include)])
+		}
+
+		dest := destName(sourceFileName)
+		if other, ok := dests[dest]; ok {
+			probs.add(source, fmt.Errorf("renders to %s, same as %s", dest, other))
+			continue
+		}
+		dests[dest] = source
+
+		// Drafts and scheduled posts are only built for previewing.
+		var banner string
+		switch {
+		case meta.Draft:
+			banner = "Draft, this post is not published yet."
+		case meta.Date.After(now):
+			banner = "Scheduled, this post will be published on " + meta.Date.Format("2006-01-02") + "."
+		}
+		if banner != "" && !b.site.Drafts {
+			continue
+		}
+
+		blogPost := page{
+			Source:      source,
+			Dest:        dest,
+			Path:        "/blog/" + dest,
+			Title:       meta.Title,
+			Date:        meta.Date,
+			Description: meta.Description,
+			Tags:        meta.Tags,
+			Draft:       meta.Draft,
+			Banner:      banner,
+			Image:       meta.Image,
+			NoTOC:       meta.NoTOC,
+			Markdown:    body,
+			Article:     true,
+			Includes:    includes,
+			key:         key,
+			// Leaving Prev and Next to the next step,
+			// posts are only in order after sorting.
+		}
+		blogPosts = append(blogPosts, blogPost)
+	}
+
+	if b.site.CheckExamples {
+		checkExamples(probs, blogPosts)
+	}
+
+	// Front matter dates may not follow the file names order.
+	slices.SortStableFunc(blogPosts, func(a, b page) int {
+		return a.Date.Compare(b.Date)
+	})
+
+	// Posts without an image of their own get a generated preview card.
+	logo, logoKey := b.loadLogo()
+	if len(blogPosts) > 0 {
+		err = os.MkdirAll(filepath.Join(out, cardDir), 0755)
+		probs.add(out, err)
+	}
+	for i, blogPost := range blogPosts {
+		if blogPost.Image != "" {
+			continue
+		}
+		blogPosts[i].Image = cardPath(blogPost)
+
+		key := hashOf(baseKey, logoKey, blogPost.Title, blogPost.Date.String())
+		err := b.writeOutput(out, blogPosts[i].Image, key, func() ([]byte, error) {
+			return b.card(blogPost, logo)
+		})
+		if err == nil {
+			// Pages link the fingerprinted card, named after its content.
+			err = b.fingerprintAsset(out, blogPosts[i].Image)
+			blogPosts[i].key = hashOf(blogPosts[i].key, key)
+		}
+		probs.add(blogPost.Source, err)
+	}
+
+	// Pages listing posts depend on all of them, so adding, removing or
+	// changing any post renders them again.
+	siteKey := baseKey
+	for _, blogPost := range blogPosts {
+		siteKey = hashOf(siteKey, blogPost.Path, blogPost.Banner, blogPost.key)
+	}
+
+	// Write the blog pages.
+	for i, blogPost := range blogPosts {
+		if i > 0 {
+			blogPost.Prev = blogPosts[i-1].Dest
+		}
+		if i+1 < len(blogPosts) {
+			blogPost.Next = blogPosts[i+1].Dest
+		}
+		blogPost.key = hashOf(baseKey, blogPost.key, blogPost.Banner, blogPost.Prev, blogPost.Next)
+
+		probs.add(blogPost.Source, b.writePage(out, blogPost))
+		if blogPost.Banner == "" {
+			published = append(published, blogPost)
+		}
+	}
+
+	// Write the index pages.
+	indexPages, err := b.index(blogPosts, b.site.PostsPerPage)
+	probs.add("", err)
+	if len(indexPages) > 1 {
+		// Only create it when needed, embed fails on empty directories.
+		err = os.MkdirAll(filepath.Join(out, indexDir), 0755)
+		probs.add(out, err)
+	}
+	for _, indexPage := range indexPages {
+		indexPage.key = siteKey
+		probs.add("", b.writePage(out, indexPage))
+		published = append(published, indexPage)
+	}
+
+	// Write the tag pages.
+	err = os.MkdirAll(filepath.Join(out, tagsDir), 0755)
+	probs.add(out, err)
+	tagPages, err := b.taxonomy(blogPosts)
+	probs.add("", err)
+	for _, tagPage := range tagPages {
+		tagPage.key = siteKey
+		probs.add("", b.writePage(out, tagPage))
+		published = append(published, tagPage)
+	}
+
+	// Write the archive page.
+	archivePage := page{
+		Title: "Archive",
+		Path:  "/archive.html",
+		Date:  lastUpdate(blogPosts),
+		Image: b.site.Image,
+	}
+	err = b.writeOutput(out, archivePage.Path, siteKey, func() ([]byte, error) {
+		// The archive lists the reading time of every post.
+		for i := range blogPosts {
+			b.render(&blogPosts[i])
+		}
+		content, err := archive(blogPosts)
+		if err != nil {
+			return nil, err
+		}
+		archivePage.Content = content
+		return b.renderPage(archivePage)
+	})
+	probs.add("", err)
+	published = append(published, archivePage)
+
+	// Write the feeds.
+	feeds := []struct {
+		name  string
+		build func([]page) ([]byte, error)
+	}{
+		{name: rssFile, build: b.rssFeed},
+		{name: atomFile, build: b.atom},
+	}
+	for _, feed := range feeds {
+		err := b.writeOutput(out, feed.name, siteKey, func() ([]byte, error) {
+			// Feeds carry the whole content of every post.
+			for i := range blogPosts {
+				b.render(&blogPosts[i])
+			}
+			return feed.build(blogPosts)
+		})
+		probs.add("", err)
+	}
+
+	// Write the sitemap and the robots.txt pointing to it.
+	err = b.writeOutput(out, sitemapFile, siteKey, func() ([]byte, error) {
+		return b.sitemap(published)
+	})
+	probs.add("", err)
+	err = b.writeOutput(out, robotsFile, baseKey, func() ([]byte, error) {
+		return b.robots(), nil
+	})
+	probs.add("", err)
+
+	// Only once every file is written.
+	b.compressAssets(probs, out)
+}
+
+// writePage renders p and writes it to its path in out, unless the last
+// build has it already.
+func (b *builder) writePage(out string, p page) error {
+	if p.Source != "" {
+		b.pageSources[p.Path] = p.Source
+	}
+	return b.writeOutput(out, p.Path, p.key, func() ([]byte, error) {
+		b.render(&p)
+		return b.renderPage(p)
+	})
+}
+
+// loadLogo reads the site image, used in the preview cards. Cards are
+// fine without it, so any problem just leaves it out.
+func (b *builder) loadLogo() (image.Image, string) {
+	if b.site.StaticDir == "" {
+		return nil, ""
+	}
+
+	path := filepath.Join(b.site.StaticDir, filepath.FromSlash(b.site.Image))
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return nil, ""
+	}
+
+	logo, err := png.Decode(bytes.NewReader(content))
+	if err != nil {
+		return nil, ""
+	}
+	return logo, b.thisBuild.input(path, content)
+}
+
+// generatorKey identifies the generator build, so changes to it render
+// everything again.
+func generatorKey() string {
+	exe, err := os.Executable()
+	if err != nil {
+		return ""
+	}
+	b, err := os.ReadFile(exe)
+	if err != nil {
+		return ""
+	}
+	return hashOf(string(b))
+}
+
+// postMeta reads the metadata of a post from its front matter. Files
+// without one rely on the YYYY_MM_DD-title.md convention, and on the
+// headings their body starts with.
+func postMeta(fileName string, md []byte) (frontMatter, []byte, error) {
+	meta, body, found, err := parseFrontMatter(md)
+	if err != nil || found {
+		return meta, body, err
+	}
+
+	meta, err = fileNameMeta(fileName)
+	if err != nil {
+		return meta, body, fmt.Errorf("no front matter: %w", err)
+	}
+	meta, body = headingMeta(meta, body)
+	return meta, body, nil
+}
+
+// headingMeta takes the "##### date", "# Title" and "#### subtitle"
+// headings a body without front matter starts with out of it, the page
+// template renders them already. The title replaces the one made from the
+// file name, which lost its casing, the date is the one of the file name
+// and the subtitle, only the one right below the title, is the
+// description.
+func headingMeta(meta frontMatter, body []byte) (frontMatter, []byte) {
+	line, rest := firstLine(body)
+	if strings.HasPrefix(line, "##### ") {
+		body = rest
+		line, rest = firstLine(body)
+	}
+
+	title, ok := strings.CutPrefix(line, "# ")
+	if !ok {
+		return meta, body
+	}
+	meta.Title = strings.TrimSpace(title)
+	body = rest
+
+	line, rest = firstLine(body)
+	if subtitle, ok := strings.CutPrefix(line, "#### "); ok {
+		meta.Description = strings.TrimSpace(subtitle)
+		body = rest
+	}
+	return meta, body
+}
+
+// firstLine returns the first non blank line of md, trimmed, and the
+// lines after it.
+func firstLine(md []byte) (string, []byte) {
+	for len(md) > 0 {
+		var line []byte
+		line, md, _ = bytes.Cut(md, []byte("\n"))
+		if text := strings.TrimSpace(string(line)); text != "" {
+			return text, md
+		}
+	}
+	return "", nil
+}
+
+// fileNameMeta extracts the date and the title from a YYYY_MM_DD-title.md
+// file name, used for content files without front matter.
+func fileNameMeta(fileName string) (frontMatter, error) {
+	dateString, titleString, found := strings.Cut(fileName, "-")
+	if !found {
+		return frontMatter{}, errors.New("wrong file format, expected YYYY_MM_DD-title.md")
+	}
+
+	date, err := time.Parse("2006_01_02", dateString)
+	if err != nil {
+		return frontMatter{}, err
+	}
+
+	unformatedTitle := strings.TrimSuffix(titleString, ".md")
+	// Casers keep state, they can not be shared.
+	title := cases.Title(language.English).String(strings.ReplaceAll(unformatedTitle, "_", " "))
+
+	return frontMatter{Title: title, Date: date}, nil
+}
+
+// destName is the html file name for a content file, dropping the
+// date prefix when there is one.
+func destName(fileName string) string {
+	name := strings.TrimSuffix(fileName, ".md")
+	if _, title, found := strings.Cut(name, "-"); found {
+		name = title
+	}
+	return name + ".html"
+}
+
+type page struct {
+	Title       string
+	Date        time.Time
+	Description string
+	Tags        []string
+	Draft       bool
+	Banner      string
+	Image       string
+	Article     bool
+	Markdown    []byte
+	Content     []byte
+	HasCode     bool
+	// TOC is only built for articles, unless they opt out with NoTOC.
+	TOC   []tocEntry
+	NoTOC bool
+	// Words is the prose word count, known after rendering.
+	Words int
+
+	Source string
+	Dest   string
+	// Path is where the page is served from, relative to the site root.
+	Path string
+	// Includes are the example files the post has code from.
+	Includes []string
+
+	Prev string
+	Next string
+
+	// key identifies the inputs the page is rendered from.
+	key string
+}
+
+// render converts the markdown of p to HTML, if it was not done yet.
+func (b *builder) render(p *page) {
+	if p.Content != nil || p.Markdown == nil {
+		return
+	}
+
+	doc := parseMarkdown(p.Markdown)
+	p.HasCode = hasCode(doc)
+	p.Words = wordCount(doc)
+	if p.Article && !p.NoTOC {
+		p.TOC = tableOfContents(doc)
+	}
+	p.Content = b.renderHTML(doc)
+}
+
+// url is the absolute, canonical, address of a page.
+func (b *builder) url(p page) string {
+	return b.site.BaseURL + strings.TrimSuffix(p.Path, "index.html")
+}
+
+func parseMarkdown(md []byte) ast.Node {
+	// create markdown parser with extensions
+	extensions := parser.CommonExtensions | parser.AutoHeadingIDs | parser.NoEmptyLineBeforeBlock | parser.FencedCode
+	p := parser.NewWithExtensions(extensions)
+	return p.Parse(md)
+}
+
+func (b *builder) renderHTML(doc ast.Node) []byte {
+	// create HTML renderer with extensions
+	htmlFlags := html.CommonFlags | html.HrefTargetBlank | html.LazyLoadImages
+	opts := html.RendererOptions{Flags: htmlFlags, RenderNodeHook: b.renderNode}
+	renderer := html.NewRenderer(opts)
+
+	return markdown.Render(doc, renderer)
+}
+
+// renderNode is where the nodes rendered differently from the default
+// are handled.
+func (b *builder) renderNode(w io.Writer, node ast.Node, entering bool) (ast.WalkStatus, bool) {
+	switch node := node.(type) {
+	case *ast.CodeBlock:
+		return renderCode(w, node, entering)
+	case *ast.Heading:
+		return renderHeading(w, node, entering)
+	case *ast.Image:
+		// Pointing to the fingerprinted assets, rendering them as usual.
+		node.Destination = []byte(b.assetURL(string(node.Destination)))
+	case *ast.Link:
+		node.Destination = []byte(b.assetURL(string(node.Destination)))
+	}
+	return ast.GoToNext, false
+}
+
+// hasCode tells if doc has any code blocks.
+func hasCode(doc ast.Node) bool {
+	found := false
+	ast.WalkFunc(doc, func(node ast.Node, entering bool) ast.WalkStatus {
+		if _, ok := node.(*ast.CodeBlock); ok {
+			found = true
+			return ast.Terminate
+		}
+		return ast.GoToNext
+	})
+	return found
+}
+
+//go:embed templates/*
+var templates embed.FS
+
+// loadTemplates parses the page templates, returning a key identifying
+// their content. Files found in dir take precedence over the built in
+// ones, dir may be empty.
+func (b *builder) loadTemplates(dir string) (string, error) {
+	builtIn, err := fs.Sub(templates, "templates")
+	if err != nil {
+		return "", err
+	}
+
+	parse := func(name string) (*template.Template, string, error) {
+		fsys, path := builtIn, "templates/"+name
+		if dir != "" {
+			if _, err := os.Stat(filepath.Join(dir, name)); err == nil {
+				fsys, path = os.DirFS(dir), filepath.Join(dir, name)
+			}
+		}
+		text, err := fs.ReadFile(fsys, name)
+		if err != nil {
+			return nil, "", buildError{Path: path, Err: err}
+		}
+
+		tmpl, err := template.New(name).Funcs(template.FuncMap{"asset": b.assetURL}).Parse(string(text))
+		if err != nil {
+			return nil, "", buildError{Path: path, Err: err}
+		}
+		return tmpl, b.thisBuild.input(path, text), nil
+	}
+
+	tmpls := map[string]**template.Template{
+		"page.html":  &b.pageTemplate,
+		"index.html": &b.indexTemplate,
+		"tag.html":   &b.tagTemplate,
+		"tags.html":  &b.tagsTemplate,
+	}
+	var key string
+	for _, name := range slices.Sorted(maps.Keys(tmpls)) {
+		tmpl, hash, err := parse(name)
+		if err != nil {
+			return "", err
+		}
+		*tmpls[name] = tmpl
+		key = hashOf(key, hash)
+	}
+
+	return key, nil
+}
+
+// renderPage renders p, with its content already rendered, into the page
+// template.
+func (b *builder) renderPage(p page) ([]byte, error) {
+	b.buf.Reset()
+
+	args := struct {
+		Site        Config
+		Title       string
+		Subtitle    string
+		Description string
+		Date        string
+		Published   string
+		Image       string
+		Card        string
+		Article     bool
+		JSONLD      template.JS
+		Content     template.HTML
+		URL         string
+		Prev        string
+		Next        string
+		Tags        []tagLink
+		Banner      string
+		HasCode     bool
+		TOC         []tocEntry
+		LongDate    string
+		Words       int
+		ReadingTime int
+	}{
+		Site:        b.site,
+		Title:       p.Title,
+		Description: b.description(p),
+		URL:         b.url(p),
+		Date:        p.Date.Format("2006-01-02"),
+		Published:   p.Date.Format(time.RFC3339),
+		Image:       b.absURL(b.assetURL(p.Image)),
+		Card:        "summary",
+		Article:     p.Article,
+		Content:     template.HTML(p.Content),
+		Prev:        p.Prev,
+		Next:        p.Next,
+		Tags:        p.tagLinks(),
+		Banner:      p.Banner,
+		HasCode:     p.HasCode,
+		TOC:         p.TOC,
+		LongDate:    longDate(p.Date),
+		Words:       p.Words,
+		ReadingTime: readingTime(p.Words),
+	}
+
+	// Posts render their title from the metadata, above the table of
+	// contents, the markdown starts with the post itself.
+	if p.Article {
+		args.Subtitle = p.Description
+	}
+
+	// The site image is a small square, only dedicated ones look good large.
+	if p.Image != b.site.Image {
+		args.Card = "summary_large_image"
+	}
+
+	jsonLD, err := b.jsonLD(p)
+	if err != nil {
+		return nil, err
+	}
+	// Marshalled by jsonLD, safe to embed as it is.
+	args.JSONLD = template.JS(jsonLD)
+
+	err = b.pageTemplate.Execute(&b.buf, args)
+	if err != nil {
+		return nil, err
+	}
+
+	return b.buf.Bytes(), nil
+}
+
+var archiveTemplate = template.Must(template.New("archive").Parse(archiveText))
+
+func archive(pages []page) ([]byte, error) {
+	type item struct {
+		Date        string
+		Title       string
+		Dest        string
+		ReadingTime int
+	}
+
+	args := struct{ Items []item }{Items: make([]item, len(pages))}
+	for i, page := range pages {
+		item := item{
+			Date:  page.Date.Format("2006/01/02"),
+			Title: page.Title,
+			Dest:  "/blog/" + page.Dest,
+
+			ReadingTime: readingTime(page.Words),
+		}
+		args.Items[len(pages)-1-i] = item
+	}
+
+	var content bytes.Buffer
+	err := archiveTemplate.Execute(&content, args)
+	if err != nil {
+		return nil, err
+	}
+
+	return content.Bytes(), nil
+}
+
+const archiveText = `
+      <header>
+        <h1>Archive</h1>
+      </header>
+
+      <section class="archive">
+        <ol class="archive-list">
+          {{range $index, $element := .Items}}
+          <li>
+            <span class="date">
+              {{$element.Date}} - 
+            </span>
+            <a href="{{$element.Dest}}">
+              {{$element.Title}}
+            </a>
+            <span class="date">
+              ({{$element.ReadingTime}} min read)
+            </span>
+          </li>
+          {{end}}
+        </ol>
+      </section>
+`
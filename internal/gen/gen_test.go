@@ -0,0 +1,113 @@
+package gen
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestRenderWithoutFrontMatter(t *testing.T) {
+	const source = "##### January 11th, 2022\n\n" +
+		"# Simple rules to avoid some range for loop pitfalls\n" +
+		"#### Go \"range\" statement memory efficiency and its implications.\n\n" +
+		"Memory efficiency is something worth aiming for.\n\n" +
+		"## Pointers\n\nEvery iteration reuses the same variable.\n\n" +
+		"#### Not a subtitle\n"
+
+	meta, body, err := postMeta("2022_01_11-simple_rules_to_avoid_some_range_for_loop_pitfalls.md", []byte(source))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	b, _ := feedBuilder()
+	if _, err := b.loadTemplates(""); err != nil {
+		t.Fatal(err)
+	}
+	p := page{
+		Path:        "/blog/simple_rules_to_avoid_some_range_for_loop_pitfalls.html",
+		Title:       meta.Title,
+		Date:        meta.Date,
+		Description: meta.Description,
+		Markdown:    body,
+		Article:     true,
+	}
+	b.render(&p)
+	got, err := b.renderPage(p)
+	if err != nil {
+		t.Fatal(err)
+	}
+	html := string(got)
+
+	if want := "<h1>Simple rules to avoid some range for loop pitfalls</h1>"; !strings.Contains(html, want) {
+		t.Errorf("page has no %s", want)
+	}
+	if n := strings.Count(html, "<h1"); n != 1 {
+		t.Errorf("page has %d titles, want 1", n)
+	}
+	if n := strings.Count(html, "January 11th, 2022"); n != 1 {
+		t.Errorf("page has the date %d times, want 1", n)
+	}
+	if want := "<h4>Go &#34;range&#34; statement memory efficiency and its implications.</h4>"; !strings.Contains(html, want) {
+		t.Errorf("page has no %s", want)
+	}
+	if n := strings.Count(html, "range&#34; statement"); n != 4 {
+		// The subtitle and the description, og:description and
+		// twitter:description meta tags.
+		t.Errorf("page has the subtitle %d times, want 4", n)
+	}
+	if !strings.Contains(html, "Not a subtitle") {
+		t.Error("page lost a subtitle of the body")
+	}
+	if !strings.Contains(html, `<h2 id="pointers">Pointers`) {
+		t.Error("page lost the headings of the body")
+	}
+}
+
+func TestBuildChecksImages(t *testing.T) {
+	dir := t.TempDir()
+	content := filepath.Join(dir, "content")
+	if err := os.Mkdir(content, 0755); err != nil {
+		t.Fatal(err)
+	}
+	files := map[string]string{
+		"about.md":      "# About\n",
+		"found.md":      "---\ntitle: Found\ndate: 2022-01-01\nimage: /images/cesar_gopher.png\n---\n\nText.\n",
+		"missing.md":    "---\ntitle: Missing\ndate: 2022-02-01\nimage: /images/cesar_gohper.png\n---\n\nText.\n",
+		"elsewhere.md":  "---\ntitle: Elsewhere\ndate: 2022-03-01\nimage: https://example.org/card.png\n---\n\nText.\n",
+		"not_a_file.md": "---\ntitle: Directory\ndate: 2022-04-01\nimage: /images\n---\n\nText.\n",
+	}
+	for name, text := range files {
+		if err := os.WriteFile(filepath.Join(content, name), []byte(text), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	err := Build(Config{
+		Title:        "Test site",
+		BaseURL:      "https://example.com",
+		Image:        "/images/cesar_gopher.png",
+		SourceDir:    content,
+		OutputDir:    filepath.Join(dir, "public"),
+		StaticDir:    filepath.Join("..", "..", "static"),
+		PostsPerPage: 5,
+	})
+
+	var probs problems
+	if !errors.As(err, &probs) {
+		t.Fatalf("got error %v, want the problems of the build", err)
+	}
+	want := []string{
+		filepath.Join(content, "missing.md") + ": image /images/cesar_gohper.png is not in the site",
+		filepath.Join(content, "not_a_file.md") + ": image /images is not in the site",
+	}
+	var got []string
+	for _, prob := range probs {
+		got = append(got, prob.Error())
+	}
+	if !slices.Equal(got, want) {
+		t.Errorf("got problems\n%s\nwant\n%s", strings.Join(got, "\n"), strings.Join(want, "\n"))
+	}
+}
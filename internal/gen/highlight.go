@@ -0,0 +1,305 @@
+package gen
+
+import (
+	"bytes"
+	"io"
+	"slices"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/gomarkdown/markdown/ast"
+	"github.com/gomarkdown/markdown/html"
+)
+
+// syntax describes just enough of a language to highlight the snippets of
+// the posts, it is not meant to be a complete lexer.
+type syntax struct {
+	keywords  []string
+	builtins  []string
+	constants []string
+	// comments are the line comment prefixes.
+	comments      []string
+	blockComments [][2]string
+	strings       []delimiter
+	// calls marks identifiers followed by a parenthesis as functions.
+	calls bool
+	// variables marks shell like $NAME and ${...} as variables.
+	variables bool
+	// ignoreCase for languages with case insensitive keywords.
+	ignoreCase bool
+	// dashes allows identifiers with dashes in them.
+	dashes bool
+}
+
+type delimiter struct {
+	open, close string
+	// escapes tells if a backslash escapes the next character.
+	escapes bool
+}
+
+// syntaxes by the name used in the code fences.
+var syntaxes = map[string]*syntax{
+	"go": {
+		keywords: []string{
+			"break", "case", "chan", "const", "continue", "default", "defer",
+			"else", "fallthrough", "for", "func", "go", "goto", "if", "import",
+			"interface", "map", "package", "range", "return", "select",
+			"struct", "switch", "type", "var",
+		},
+		builtins: []string{
+			"any", "bool", "byte", "comparable", "complex64", "complex128",
+			"error", "float32", "float64", "int", "int8", "int16", "int32",
+			"int64", "rune", "string", "uint", "uint8", "uint16", "uint32",
+			"uint64", "uintptr", "append", "cap", "clear", "close", "complex",
+			"copy", "delete", "imag", "len", "make", "max", "min", "new",
+			"panic", "print", "println", "real", "recover",
+		},
+		constants:     []string{"true", "false", "iota", "nil"},
+		comments:      []string{"//"},
+		blockComments: [][2]string{{"/*", "*/"}},
+		strings: []delimiter{
+			{"`", "`", false},
+			{`"`, `"`, true},
+			{"'", "'", true},
+		},
+		calls: true,
+	},
+	"bash": {
+		keywords: []string{
+			"if", "then", "else", "elif", "fi", "for", "while", "until", "do",
+			"done", "case", "esac", "in", "function", "return", "local",
+			"export", "readonly", "set", "unset", "shift", "exit",
+		},
+		builtins: []string{
+			"cd", "echo", "printf", "read", "source", "test", "pushd", "popd",
+			"eval", "exec", "trap",
+		},
+		comments: []string{"#"},
+		strings: []delimiter{
+			{`"`, `"`, true},
+			{"'", "'", false},
+		},
+		variables: true,
+		dashes:    true,
+	},
+	"nix": {
+		keywords: []string{
+			"let", "in", "with", "rec", "inherit", "if", "then", "else",
+			"assert", "or",
+		},
+		builtins: []string{
+			"builtins", "import", "throw", "abort", "derivation", "map",
+			"toString", "baseNameOf", "dirOf", "isNull", "removeAttrs",
+		},
+		constants:     []string{"true", "false", "null"},
+		comments:      []string{"#"},
+		blockComments: [][2]string{{"/*", "*/"}},
+		strings: []delimiter{
+			{"''", "''", false},
+			{`"`, `"`, true},
+		},
+		dashes: true,
+	},
+	"sql": {
+		keywords: []string{
+			"add", "alter", "and", "as", "asc", "begin", "between", "by",
+			"call", "case", "create", "declare", "default", "delete", "desc",
+			"distinct", "drop", "else", "end", "exists", "from", "function",
+			"group", "having", "if", "in", "index", "inner", "insert", "into",
+			"is", "join", "key", "left", "like", "limit", "not", "null", "on",
+			"or", "order", "out", "primary", "procedure", "references",
+			"return", "returns", "right", "select", "set", "table", "then",
+			"union", "unique", "unsigned", "update", "values", "when",
+			"where",
+		},
+		builtins: []string{
+			"bigint", "char", "date", "datetime", "decimal", "int",
+			"integer", "text", "timestamp", "varchar", "count", "now",
+			"timestampadd", "coalesce", "second", "minute", "hour", "day",
+		},
+		constants: []string{"true", "false"},
+		comments:  []string{"--"},
+		strings: []delimiter{
+			{"'", "'", true},
+			{`"`, `"`, true},
+		},
+		ignoreCase: true,
+	},
+}
+
+func init() {
+	syntaxes["sh"] = syntaxes["bash"]
+	syntaxes["shell"] = syntaxes["bash"]
+	syntaxes["golang"] = syntaxes["go"]
+}
+
+// renderCode is a RenderNodeHook highlighting fenced code blocks. Code in
+// languages without a syntax is only escaped.
+func renderCode(w io.Writer, node ast.Node, entering bool) (ast.WalkStatus, bool) {
+	block, ok := node.(*ast.CodeBlock)
+	if !ok {
+		return ast.GoToNext, false
+	}
+
+	lang, _, _ := strings.Cut(string(block.Info), " ")
+	io.WriteString(w, `<pre class="code">`)
+	if lang != "" {
+		io.WriteString(w, `<code class="language-`)
+		html.EscapeHTML(w, []byte(lang))
+		io.WriteString(w, `">`)
+	} else {
+		io.WriteString(w, "<code>")
+	}
+	highlight(w, block.Literal, syntaxes[strings.ToLower(lang)])
+	io.WriteString(w, "</code></pre>\n")
+
+	return ast.GoToNext, true
+}
+
+// highlight writes the code, escaped, with its tokens wrapped in spans
+// classed by their kind. A nil syntax does no highlighting.
+func highlight(w io.Writer, code []byte, s *syntax) {
+	if s == nil {
+		html.EscapeHTML(w, code)
+		return
+	}
+
+	token := func(class string, text []byte) {
+		if class == "" {
+			html.EscapeHTML(w, text)
+			return
+		}
+		io.WriteString(w, `<span class="hl-`+class+`">`)
+		html.EscapeHTML(w, text)
+		io.WriteString(w, "</span>")
+	}
+
+	for i := 0; i < len(code); {
+		rest := code[i:]
+		n, class := s.next(code, i)
+		if n == 0 {
+			_, n = utf8.DecodeRune(rest)
+		}
+		token(class, rest[:n])
+		i += n
+	}
+}
+
+// next scans the token starting at code[i], returning its length and
+// class. Plain text has no class.
+func (s *syntax) next(code []byte, i int) (int, string) {
+	rest := code[i:]
+	wordStart := i == 0 || isSpace(code[i-1])
+
+	for _, c := range s.blockComments {
+		if bytes.HasPrefix(rest, []byte(c[0])) {
+			end := bytes.Index(rest[len(c[0]):], []byte(c[1]))
+			if end < 0 {
+				return len(rest), "comment"
+			}
+			return len(c[0]) + end + len(c[1]), "comment"
+		}
+	}
+
+	for _, c := range s.comments {
+		// Shell and nix only take # as a comment at the start of a word.
+		if bytes.HasPrefix(rest, []byte(c)) && (c != "#" || wordStart) {
+			if end := bytes.IndexByte(rest, '\n'); end >= 0 {
+				return end, "comment"
+			}
+			return len(rest), "comment"
+		}
+	}
+
+	for _, d := range s.strings {
+		if !bytes.HasPrefix(rest, []byte(d.open)) {
+			continue
+		}
+		for j := len(d.open); j < len(rest); j++ {
+			if d.escapes && rest[j] == '\\' {
+				j++
+				continue
+			}
+			if bytes.HasPrefix(rest[j:], []byte(d.close)) {
+				return j + len(d.close), "string"
+			}
+		}
+		return len(rest), "string"
+	}
+
+	if s.variables && rest[0] == '$' && len(rest) > 1 {
+		if rest[1] == '{' {
+			if end := bytes.IndexByte(rest, '}'); end >= 0 {
+				return end + 1, "variable"
+			}
+		}
+		if n := s.word(rest[1:]); n > 0 {
+			return n + 1, "variable"
+		}
+		if strings.IndexByte("0123456789@#?$!*_-", rest[1]) >= 0 {
+			return 2, "variable"
+		}
+	}
+
+	r, _ := utf8.DecodeRune(rest)
+	if unicode.IsDigit(r) {
+		n := 0
+		for n < len(rest) && (isAlnum(rest[n]) || rest[n] == '.') {
+			n++
+		}
+		return n, "number"
+	}
+
+	if n := s.word(rest); n > 0 {
+		word := string(rest[:n])
+		if s.ignoreCase {
+			word = strings.ToLower(word)
+		}
+		switch {
+		case slices.Contains(s.keywords, word):
+			return n, "keyword"
+		case slices.Contains(s.constants, word):
+			return n, "constant"
+		case slices.Contains(s.builtins, word):
+			return n, "builtin"
+		case s.calls && n < len(rest) && rest[n] == '(':
+			return n, "function"
+		}
+		return n, ""
+	}
+
+	n := 0
+	for n < len(rest) && strings.IndexByte("+-*/%=<>!&|^:~", rest[n]) >= 0 {
+		n++
+	}
+	if n > 0 {
+		return n, "operator"
+	}
+	return 0, ""
+}
+
+// word returns the length of the identifier at the start of b.
+func (s *syntax) word(b []byte) int {
+	n := 0
+	for n < len(b) {
+		r, size := utf8.DecodeRune(b[n:])
+		switch {
+		case r == '_' || unicode.IsLetter(r):
+		case n > 0 && unicode.IsDigit(r):
+		case n > 0 && s.dashes && r == '-':
+		default:
+			return n
+		}
+		n += size
+	}
+	return n
+}
+
+func isSpace(b byte) bool {
+	return b == ' ' || b == '\t' || b == '\n'
+}
+
+func isAlnum(b byte) bool {
+	return b >= '0' && b <= '9' || b >= 'a' && b <= 'z' || b >= 'A' && b <= 'Z' || b == '_'
+}
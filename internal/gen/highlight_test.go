@@ -0,0 +1,156 @@
+package gen
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/gomarkdown/markdown/ast"
+)
+
+func TestHighlight(t *testing.T) {
+	tests := []struct {
+		name string
+		lang string
+		code string
+		want string
+	}{
+		{
+			name: "go string and comment",
+			lang: "go",
+			code: `x := "a\"b" // <c>`,
+			want: `x <span class="hl-operator">:=</span> <span class="hl-string">&quot;a\&quot;b&quot;</span> <span class="hl-comment">// &lt;c&gt;</span>`,
+		},
+		{
+			name: "go raw string",
+			lang: "go",
+			code: "`a\\` + `b",
+			want: "<span class=\"hl-string\">`a\\`</span> <span class=\"hl-operator\">+</span> <span class=\"hl-string\">`b</span>",
+		},
+		{
+			name: "go rune",
+			lang: "go",
+			code: `'\''`,
+			want: `<span class="hl-string">'\''</span>`,
+		},
+		{
+			name: "go block comment",
+			lang: "go",
+			code: "/* a\n b */x",
+			want: "<span class=\"hl-comment\">/* a\n b */</span>x",
+		},
+		{
+			name: "go unclosed block comment",
+			lang: "go",
+			code: "x /* a",
+			want: `x <span class="hl-comment">/* a</span>`,
+		},
+		{
+			name: "go words",
+			lang: "go",
+			code: "func f() { return len(nil), g.Do(1.5e3) }",
+			want: `<span class="hl-keyword">func</span> <span class="hl-function">f</span>() { ` +
+				`<span class="hl-keyword">return</span> <span class="hl-builtin">len</span>(<span class="hl-constant">nil</span>), ` +
+				`g.<span class="hl-function">Do</span>(<span class="hl-number">1.5e3</span>) }`,
+		},
+		{
+			name: "go identifiers containing keywords",
+			lang: "golang",
+			code: "format_func2 := lenient",
+			want: `format_func2 <span class="hl-operator">:=</span> lenient`,
+		},
+		{
+			name: "go unicode",
+			lang: "go",
+			code: `café := "ü"`,
+			want: `café <span class="hl-operator">:=</span> <span class="hl-string">&quot;ü&quot;</span>`,
+		},
+		{
+			name: "bash",
+			lang: "bash",
+			code: "echo \"$HOME\" 'a\\' # note\nnix-build --dry-run ${OUT} $1",
+			want: `<span class="hl-builtin">echo</span> <span class="hl-string">&quot;$HOME&quot;</span> <span class="hl-string">'a\'</span> <span class="hl-comment"># note</span>` + "\n" +
+				`nix-build <span class="hl-operator">--</span>dry-run <span class="hl-variable">${OUT}</span> <span class="hl-variable">$1</span>`,
+		},
+		{
+			name: "bash hash inside a word",
+			lang: "sh",
+			code: "if [ a#b ]; then exit; fi",
+			want: `<span class="hl-keyword">if</span> [ a#b ]; <span class="hl-keyword">then</span> <span class="hl-keyword">exit</span>; <span class="hl-keyword">fi</span>`,
+		},
+		{
+			name: "nix",
+			lang: "nix",
+			code: "let x = ''\n  a \"b\"\n''; in builtins.map f [ true ] # c\n/* d */",
+			want: `<span class="hl-keyword">let</span> x <span class="hl-operator">=</span> <span class="hl-string">''` + "\n" + `  a &quot;b&quot;` + "\n" + `''</span>; ` +
+				`<span class="hl-keyword">in</span> <span class="hl-builtin">builtins</span>.<span class="hl-builtin">map</span> f [ <span class="hl-constant">true</span> ] <span class="hl-comment"># c</span>` + "\n" +
+				`<span class="hl-comment">/* d */</span>`,
+		},
+		{
+			name: "sql",
+			lang: "sql",
+			code: "SELECT count(*) FROM buckets WHERE id = 'it\\'s' -- c",
+			want: `<span class="hl-keyword">SELECT</span> <span class="hl-builtin">count</span>(<span class="hl-operator">*</span>) ` +
+				`<span class="hl-keyword">FROM</span> buckets <span class="hl-keyword">WHERE</span> id <span class="hl-operator">=</span> ` +
+				`<span class="hl-string">'it\'s'</span> <span class="hl-comment">-- c</span>`,
+		},
+		{
+			name: "sql lower case",
+			lang: "sql",
+			code: "update t set n = n - 1",
+			want: `<span class="hl-keyword">update</span> t <span class="hl-keyword">set</span> n <span class="hl-operator">=</span> n <span class="hl-operator">-</span> <span class="hl-number">1</span>`,
+		},
+		{
+			name: "unknown language",
+			lang: "python",
+			code: `# <b> "c" & 'd'`,
+			want: `# &lt;b&gt; &quot;c&quot; &amp; 'd'`,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			highlight(&buf, []byte(test.code), syntaxes[test.lang])
+			if got := buf.String(); got != test.want {
+				t.Errorf("got:\n%s\nwant:\n%s", got, test.want)
+			}
+		})
+	}
+}
+
+func TestRenderCode(t *testing.T) {
+	tests := []struct {
+		info string
+		code string
+		want string
+	}{
+		{
+			info: "Go",
+			code: "nil\n",
+			want: `<pre class="code"><code class="language-Go"><span class="hl-constant">nil</span>` + "\n</code></pre>\n",
+		},
+		{
+			info: "python title=\"x\"",
+			code: "None\n",
+			want: `<pre class="code"><code class="language-python">None` + "\n</code></pre>\n",
+		},
+		{
+			info: "<x>",
+			code: "<y>\n",
+			want: `<pre class="code"><code class="language-&lt;x&gt;">&lt;y&gt;` + "\n</code></pre>\n",
+		},
+		{
+			code: "true\n",
+			want: "<pre class=\"code\"><code>true\n</code></pre>\n",
+		},
+	}
+	for _, test := range tests {
+		var buf bytes.Buffer
+		block := &ast.CodeBlock{Leaf: ast.Leaf{Literal: []byte(test.code)}, Info: []byte(test.info)}
+		if _, ok := renderCode(&buf, block, true); !ok {
+			t.Fatalf("code block %q not rendered", test.info)
+		}
+		if got := buf.String(); got != test.want {
+			t.Errorf("info %q, got:\n%s\nwant:\n%s", test.info, got, test.want)
+		}
+	}
+}
@@ -0,0 +1,197 @@
+package gen
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// Posts include code from the examples directory, so listings can not
+// drift from code that compiles. The syntax is the one of the markdown
+// parser code includes, on a line of its own:
+//
+//	<{{2022_01_26/final/main.go}}
+//	<{{2022_01_26/final/main.go}}[12,30]
+//	<{{2022_01_26/final/main.go}}[requester]
+//
+// The address is optional, either a range of lines, both included, or a
+// region delimited in the code by comments ending in "region <name>" and
+// "endregion <name>".
+//
+// Includes are expanded when the post is loaded, instead of using the
+// parser extension, so missing code fails the build.
+
+// expandIncludes replaces the includes of md, outside of code blocks, with
+// a fenced block of the included code. The included files are recorded as
+// inputs of the build and returned.
+func (b *builder) expandIncludes(md []byte) ([]byte, []string, error) {
+	var out bytes.Buffer
+	var includes []string
+	var fence string
+
+	scanner := bufio.NewScanner(bytes.NewReader(md))
+	for line := 1; scanner.Scan(); line++ {
+		text := scanner.Text()
+
+		// Includes in code blocks are just code.
+		trimmed := strings.TrimSpace(text)
+		switch {
+		case fence == "" && (strings.HasPrefix(trimmed, "```") || strings.HasPrefix(trimmed, "~~~")):
+			fence = trimmed[:3]
+		case fence != "" && strings.HasPrefix(trimmed, fence):
+			fence = ""
+		}
+
+		file, address, ok := parseInclude(trimmed)
+		if fence != "" || !ok {
+			out.WriteString(text)
+			out.WriteByte('\n')
+			continue
+		}
+
+		code, source, err := b.include(file, address)
+		if err != nil {
+			return nil, nil, lineError{Line: line, Err: fmt.Errorf("include %s: %w", file, err)}
+		}
+		if !slices.Contains(includes, source) {
+			includes = append(includes, source)
+		}
+
+		out.WriteString("```" + strings.TrimPrefix(path.Ext(file), ".") + "\n")
+		out.Write(code)
+		out.WriteString("```\n")
+	}
+
+	return out.Bytes(), includes, scanner.Err()
+}
+
+// parseInclude parses a <{{file}}[address] line.
+func parseInclude(line string) (file, address string, ok bool) {
+	rest, ok := strings.CutPrefix(line, "<{{")
+	if !ok {
+		return "", "", false
+	}
+	file, rest, ok = strings.Cut(rest, "}}")
+	if !ok || file == "" {
+		return "", "", false
+	}
+	if rest == "" {
+		return file, "", true
+	}
+	if !strings.HasPrefix(rest, "[") || !strings.HasSuffix(rest, "]") {
+		return "", "", false
+	}
+	return file, rest[1 : len(rest)-1], true
+}
+
+// include reads the code at address in file, relative to the examples
+// directory, returning it with the path of the file.
+func (b *builder) include(file, address string) ([]byte, string, error) {
+	if b.site.ExamplesDir == "" {
+		return nil, "", errors.New("no examples directory configured")
+	}
+	if !filepath.IsLocal(filepath.FromSlash(file)) {
+		return nil, "", errors.New("must be a path inside the examples directory")
+	}
+
+	source := filepath.Join(b.site.ExamplesDir, filepath.FromSlash(file))
+	content, err := os.ReadFile(source)
+	if err != nil {
+		return nil, "", err
+	}
+	b.thisBuild.input(source, content)
+
+	lines := strings.SplitAfter(string(content), "\n")
+	if lines[len(lines)-1] == "" {
+		lines = lines[:len(lines)-1]
+	}
+
+	switch from, to, isRange := strings.Cut(address, ","); {
+	case address == "":
+	case isRange:
+		first, err1 := strconv.Atoi(strings.TrimSpace(from))
+		last, err2 := strconv.Atoi(strings.TrimSpace(to))
+		if err1 != nil || err2 != nil || first < 1 || last < first {
+			return nil, "", fmt.Errorf("invalid line range %q", address)
+		}
+		if last > len(lines) {
+			return nil, "", fmt.Errorf("line range %q past the end of the file, it has %d lines", address, len(lines))
+		}
+		lines = lines[first-1 : last]
+	default:
+		lines, err = region(lines, address)
+		if err != nil {
+			return nil, "", err
+		}
+	}
+
+	// Markers are usually apart from the code, by blank lines.
+	for len(lines) > 0 && strings.TrimSpace(lines[0]) == "" {
+		lines = lines[1:]
+	}
+	for len(lines) > 0 && strings.TrimSpace(lines[len(lines)-1]) == "" {
+		lines = lines[:len(lines)-1]
+	}
+
+	code := dedent(lines)
+	if len(code) > 0 && !strings.HasSuffix(code, "\n") {
+		code += "\n"
+	}
+	return []byte(code), source, nil
+}
+
+// region returns the lines between the markers of the named region, the
+// markers themselves left out.
+func region(lines []string, name string) ([]string, error) {
+	marker := func(line, kind string) bool {
+		fields := strings.Fields(line)
+		return len(fields) >= 2 && fields[len(fields)-2] == kind && fields[len(fields)-1] == name
+	}
+
+	start := -1
+	for i, line := range lines {
+		switch {
+		case start < 0 && marker(line, "region"):
+			start = i + 1
+		case start >= 0 && marker(line, "endregion"):
+			return lines[start:i], nil
+		}
+	}
+
+	if start < 0 {
+		return nil, fmt.Errorf("region %q not found", name)
+	}
+	return nil, fmt.Errorf("region %q is not closed", name)
+}
+
+// dedent removes the indentation common to all the non blank lines.
+func dedent(lines []string) string {
+	prefix := ""
+	first := true
+	for _, line := range lines {
+		if strings.TrimSpace(line) == "" {
+			continue
+		}
+		indent := line[:len(line)-len(strings.TrimLeft(line, " \t"))]
+		if first {
+			prefix, first = indent, false
+			continue
+		}
+		for !strings.HasPrefix(indent, prefix) {
+			prefix = prefix[:len(prefix)-1]
+		}
+	}
+
+	var b strings.Builder
+	for _, line := range lines {
+		b.WriteString(strings.TrimPrefix(line, prefix))
+	}
+	return b.String()
+}
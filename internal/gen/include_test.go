@@ -0,0 +1,197 @@
+package gen
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestParseInclude(t *testing.T) {
+	tests := []struct {
+		line    string
+		file    string
+		address string
+		ok      bool
+	}{
+		{line: "<{{a/main.go}}", file: "a/main.go", ok: true},
+		{line: "<{{a/main.go}}[3,7]", file: "a/main.go", address: "3,7", ok: true},
+		{line: "<{{a/main.go}}[requester]", file: "a/main.go", address: "requester", ok: true},
+		{line: "<{{a/main.go}}[]", file: "a/main.go", ok: true},
+		{line: "<{{}}", ok: false},
+		{line: "<{{a/main.go", ok: false},
+		{line: "<{{a/main.go}}[3,7", ok: false},
+		{line: "<{{a/main.go}} and text", ok: false},
+		{line: "see <{{a/main.go}}", ok: false},
+		{line: "{{a/main.go}}", ok: false},
+	}
+	for _, test := range tests {
+		file, address, ok := parseInclude(test.line)
+		if file != test.file || address != test.address || ok != test.ok {
+			t.Errorf("parseInclude(%q) = %q, %q, %v, want %q, %q, %v", test.line, file, address, ok, test.file, test.address, test.ok)
+		}
+	}
+}
+
+const includeExample = `package main
+
+import "fmt"
+
+func main() {
+	// region greet
+	msg := "hello"
+
+		fmt.Println(msg)
+	// endregion greet
+}
+
+// region open
+func open() {}
+`
+
+func TestExpandIncludes(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.MkdirAll(filepath.Join(dir, "a"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "a", "main.go"), []byte(includeExample), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name string
+		md   string
+		want string
+		err  string
+		line int
+	}{
+		{
+			name: "whole file",
+			md:   "Intro\n<{{a/main.go}}\nOutro\n",
+			want: "Intro\n```go\n" + includeExample + "```\nOutro\n",
+		},
+		{
+			name: "line range",
+			md:   "<{{a/main.go}}[3, 3]\n",
+			want: "```go\nimport \"fmt\"\n```\n",
+		},
+		{
+			name: "line range trimming blank lines and indentation",
+			md:   "<{{a/main.go}}[7,9]\n",
+			want: "```go\nmsg := \"hello\"\n\n\tfmt.Println(msg)\n```\n",
+		},
+		{
+			name: "region",
+			md:   "  <{{a/main.go}}[greet]  \n",
+			want: "```go\nmsg := \"hello\"\n\n\tfmt.Println(msg)\n```\n",
+		},
+		{
+			name: "in a fenced block",
+			md:   "```\n<{{a/main.go}}\n```\n~~~md\n<{{a/main.go}}\n~~~\n",
+			want: "```\n<{{a/main.go}}\n```\n~~~md\n<{{a/main.go}}\n~~~\n",
+		},
+		{
+			name: "other fence in a fenced block",
+			md:   "~~~\n```\n<{{a/main.go}}\n~~~\n<{{a/main.go}}[3,3]\n",
+			want: "~~~\n```\n<{{a/main.go}}\n~~~\n```go\nimport \"fmt\"\n```\n",
+		},
+		{
+			name: "inline",
+			md:   "Use `<{{a/main.go}}` on a line of its own.\n",
+			want: "Use `<{{a/main.go}}` on a line of its own.\n",
+		},
+		{
+			name: "missing file",
+			md:   "Intro\n\n<{{a/other.go}}\n",
+			err:  "include a/other.go: open " + filepath.Join(dir, "a", "other.go") + ": no such file or directory",
+			line: 3,
+		},
+		{
+			name: "outside of the examples",
+			md:   "<{{../secret.go}}\n",
+			err:  "include ../secret.go: must be a path inside the examples directory",
+			line: 1,
+		},
+		{
+			name: "invalid range",
+			md:   "\n<{{a/main.go}}[5,2]\n",
+			err:  `include a/main.go: invalid line range "5,2"`,
+			line: 2,
+		},
+		{
+			name: "range from zero",
+			md:   "<{{a/main.go}}[0,2]\n",
+			err:  `include a/main.go: invalid line range "0,2"`,
+			line: 1,
+		},
+		{
+			name: "range past the end",
+			md:   "<{{a/main.go}}[10,20]\n",
+			err:  `include a/main.go: line range "10,20" past the end of the file, it has 14 lines`,
+			line: 1,
+		},
+		{
+			name: "unknown region",
+			md:   "```\n```\n<{{a/main.go}}[close]\n",
+			err:  `include a/main.go: region "close" not found`,
+			line: 3,
+		},
+		{
+			name: "region not closed",
+			md:   "<{{a/main.go}}[open]\n",
+			err:  `include a/main.go: region "open" is not closed`,
+			line: 1,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			b := &builder{site: Config{ExamplesDir: dir}, thisBuild: newManifest()}
+			got, includes, err := b.expandIncludes([]byte(test.md))
+			if test.err != "" {
+				var le lineError
+				if !errors.As(err, &le) {
+					t.Fatalf("got error %v, want a line error", err)
+				}
+				if le.Line != test.line || le.Err.Error() != test.err {
+					t.Fatalf("got error at line %d: %v, want at line %d: %s", le.Line, le.Err, test.line, test.err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if string(got) != test.want {
+				t.Errorf("got:\n%s\nwant:\n%s", got, test.want)
+			}
+			if len(includes) > 0 && includes[0] != filepath.Join(dir, "a", "main.go") {
+				t.Errorf("got includes %q", includes)
+			}
+		})
+	}
+}
+
+func TestIncludeNoExamplesDir(t *testing.T) {
+	b := &builder{thisBuild: newManifest()}
+	_, _, err := b.expandIncludes([]byte("<{{a/main.go}}\n"))
+	if err == nil || err.Error() != "line 1: include a/main.go: no examples directory configured" {
+		t.Errorf("got error %v", err)
+	}
+}
+
+func TestDedent(t *testing.T) {
+	tests := []struct {
+		lines []string
+		want  string
+	}{
+		{lines: nil, want: ""},
+		{lines: []string{"\ta\n", "\t\tb\n"}, want: "a\n\tb\n"},
+		{lines: []string{"\t\ta\n", "\n", "\tb\n"}, want: "\ta\n\nb\n"},
+		{lines: []string{"    a\n", "  \tb\n"}, want: "  a\n\tb\n"},
+		{lines: []string{"a\n", "\tb\n"}, want: "a\n\tb\n"},
+	}
+	for _, test := range tests {
+		if got := dedent(test.lines); got != test.want {
+			t.Errorf("dedent(%q) = %q, want %q", test.lines, got, test.want)
+		}
+	}
+}
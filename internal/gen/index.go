@@ -0,0 +1,78 @@
+package gen
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+)
+
+// indexDir holds the index pages after the first one, which is the site root.
+const indexDir = "page/"
+
+// index splits the posts, newest first, into pages of perPage posts. The
+// first page is the site root, the older ones go under indexDir.
+func (b *builder) index(pages []page, perPage int) ([]page, error) {
+	total := max((len(pages)+perPage-1)/perPage, 1)
+
+	indexPages := make([]page, 0, total)
+	for n := 1; n <= total; n++ {
+		type item struct {
+			Date    string
+			Title   string
+			Dest    string
+			Excerpt string
+		}
+
+		args := struct {
+			Heading string
+			Items   []item
+			Newer   string
+			Older   string
+		}{
+			Heading: "Recent posts",
+		}
+		if n > 1 {
+			args.Heading = fmt.Sprintf("Posts - page %d", n)
+			args.Newer = strings.TrimSuffix(indexPath(n-1), "index.html")
+		}
+		if n < total {
+			args.Older = indexPath(n + 1)
+		}
+
+		// Walking backwards, newest first.
+		last := len(pages) - (n-1)*perPage
+		first := max(last-perPage, 0)
+		for i := last - 1; i >= first; i-- {
+			args.Items = append(args.Items, item{
+				Date:    pages[i].Date.Format("2006/01/02"),
+				Title:   pages[i].Title,
+				Dest:    pages[i].Path,
+				Excerpt: pages[i].Description,
+			})
+		}
+
+		var content bytes.Buffer
+		err := b.indexTemplate.Execute(&content, args)
+		if err != nil {
+			return nil, err
+		}
+
+		indexPages = append(indexPages, page{
+			Title:   args.Heading,
+			Path:    indexPath(n),
+			Date:    lastUpdate(pages),
+			Image:   b.site.Image,
+			Content: content.Bytes(),
+		})
+	}
+
+	return indexPages, nil
+}
+
+// indexPath is the path of the nth index page, starting at 1.
+func indexPath(n int) string {
+	if n == 1 {
+		return "/index.html"
+	}
+	return fmt.Sprintf("/%s%d.html", indexDir, n)
+}
@@ -0,0 +1,136 @@
+package gen
+
+import (
+	"fmt"
+	"html"
+	"io/fs"
+	"maps"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strings"
+)
+
+var (
+	htmlComment = regexp.MustCompile(`(?s)<!--.*?-->`)
+	htmlTag     = regexp.MustCompile(`<[a-zA-Z][^>]*>`)
+	htmlAttr    = regexp.MustCompile(`\s([a-zA-Z-]+)\s*=\s*(?:"([^"]*)"|'([^']*)')`)
+)
+
+// htmlPage holds what the link check needs from a generated page.
+type htmlPage struct {
+	links []string
+	ids   map[string]bool
+}
+
+// parsePage extracts the links, href and src attributes, and the anchors,
+// id and name attributes, of an HTML page. Pages are generated, not
+// written by hand, so looking at the tags is enough.
+func parsePage(b []byte) htmlPage {
+	p := htmlPage{ids: map[string]bool{}}
+	b = htmlComment.ReplaceAll(b, nil)
+	for _, tag := range htmlTag.FindAll(b, -1) {
+		for _, attr := range htmlAttr.FindAllSubmatch(tag, -1) {
+			value := html.UnescapeString(string(attr[2]) + string(attr[3]))
+			switch strings.ToLower(string(attr[1])) {
+			case "href", "src":
+				p.links = append(p.links, value)
+			case "id", "name":
+				p.ids[value] = true
+			}
+		}
+	}
+	return p
+}
+
+// checkLinks verifies every internal link of the HTML pages in dir points
+// to a file of the site and, when it has a fragment, to an anchor of that
+// page.
+func (b *builder) checkLinks(dir string, probs *problems) {
+	pages := map[string]htmlPage{}
+	err := filepath.WalkDir(dir, func(name string, entry fs.DirEntry, err error) error {
+		if err != nil || entry.IsDir() || filepath.Ext(name) != ".html" {
+			return err
+		}
+		content, err := os.ReadFile(name)
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(dir, name)
+		if err != nil {
+			return err
+		}
+		pages["/"+filepath.ToSlash(rel)] = parsePage(content)
+		return nil
+	})
+	if err != nil {
+		probs.add(dir, err)
+		return
+	}
+
+	for _, pagePath := range slices.Sorted(maps.Keys(pages)) {
+		for _, link := range pages[pagePath].links {
+			target, fragment, internal := b.resolveLink(pagePath, link)
+			if !internal {
+				continue
+			}
+
+			var broken string
+			if info, err := os.Stat(filepath.Join(dir, filepath.FromSlash(target))); err != nil || info.IsDir() {
+				broken = "missing " + target
+			} else if fragment != "" && path.Ext(target) == ".html" && !pages[target].ids[fragment] {
+				broken = "missing anchor #" + fragment + " in " + target
+			}
+			if broken == "" {
+				continue
+			}
+
+			// Pages not rendered from content come from the templates.
+			source := b.pageSources[pagePath]
+			probs.add(source, fmt.Errorf("%s: broken link %q, %s", pagePath, link, broken))
+		}
+	}
+}
+
+// resolveLink returns the site file, and fragment, a link in the page at
+// pagePath points to. Links to other sites are not internal.
+func (b *builder) resolveLink(pagePath, link string) (target, fragment string, internal bool) {
+	u, err := url.Parse(link)
+	if err != nil {
+		return "", "", false
+	}
+
+	// Absolute links to the site itself, like canonical ones.
+	if u.IsAbs() || u.Host != "" {
+		base, err := url.Parse(b.site.BaseURL)
+		if err != nil || u.Host != base.Host || (u.Scheme != "" && u.Scheme != "http" && u.Scheme != "https") {
+			return "", "", false
+		}
+		// Only the paths under the base one are part of the site.
+		rel, ok := strings.CutPrefix(u.Path, base.Path)
+		if !ok || (rel != "" && !strings.HasPrefix(rel, "/")) {
+			return "", "", false
+		}
+		u.Path = "/" + strings.TrimPrefix(rel, "/")
+	}
+
+	target = u.Path
+	switch {
+	case target == "":
+		target = pagePath
+	case !strings.HasPrefix(target, "/"):
+		target = path.Join(path.Dir(pagePath), target)
+		// Links to ".", ".." and "dir/" all name a directory.
+		if base := path.Base(u.Path); strings.HasSuffix(u.Path, "/") || base == "." || base == ".." {
+			target += "/"
+		}
+	}
+	if target == "" || strings.HasSuffix(target, "/") {
+		target += "index.html"
+	}
+
+	return path.Clean(target), u.Fragment, true
+}
@@ -0,0 +1,262 @@
+package gen
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestParsePage(t *testing.T) {
+	tests := []struct {
+		name  string
+		html  string
+		links []string
+		ids   []string
+	}{
+		{
+			name:  "links and anchors",
+			html:  `<h2 id="intro">Intro</h2><a href="/about.html">About</a><img src='/images/a.png' alt="A">`,
+			links: []string{"/about.html", "/images/a.png"},
+			ids:   []string{"intro"},
+		},
+		{
+			name:  "escaped attributes",
+			html:  `<a href="/tags/c-c.html?a=1&amp;b=2">c</a><a name="old&#45;anchor"></a>`,
+			links: []string{"/tags/c-c.html?a=1&b=2"},
+			ids:   []string{"old-anchor"},
+		},
+		{
+			name:  "upper case and spaces",
+			html:  `<A HREF = "/x.html" ID="top">x</A>`,
+			links: []string{"/x.html"},
+			ids:   []string{"top"},
+		},
+		{
+			name: "comments",
+			html: "<!-- <a href=\"/gone.html\">\n</a> --><p>text</p>",
+		},
+		{
+			name: "text is not a tag",
+			html: `<p>if a < b href="/x.html" then</p>`,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			got := parsePage([]byte(test.html))
+			if !reflect.DeepEqual(got.links, test.links) {
+				t.Errorf("got links %q, want %q", got.links, test.links)
+			}
+			ids := map[string]bool{}
+			for _, id := range test.ids {
+				ids[id] = true
+			}
+			if !reflect.DeepEqual(got.ids, ids) {
+				t.Errorf("got ids %v, want %v", got.ids, ids)
+			}
+		})
+	}
+}
+
+func TestResolveLink(t *testing.T) {
+	tests := []struct {
+		name     string
+		baseURL  string
+		page     string
+		link     string
+		target   string
+		fragment string
+		internal bool
+	}{
+		{
+			name:     "root relative",
+			page:     "/blog/post.html",
+			link:     "/about.html",
+			target:   "/about.html",
+			internal: true,
+		},
+		{
+			name:     "relative",
+			page:     "/blog/post.html",
+			link:     "other.html",
+			target:   "/blog/other.html",
+			internal: true,
+		},
+		{
+			name:     "parent",
+			page:     "/tags/go.html",
+			link:     "../blog/post.html#intro",
+			target:   "/blog/post.html",
+			fragment: "intro",
+			internal: true,
+		},
+		{
+			name:     "parent of the root",
+			page:     "/index.html",
+			link:     "../../about.html",
+			target:   "/about.html",
+			internal: true,
+		},
+		{
+			name:     "directory",
+			page:     "/blog/post.html",
+			link:     "/tags/",
+			target:   "/tags/index.html",
+			internal: true,
+		},
+		{
+			name:     "relative directory",
+			page:     "/page/2.html",
+			link:     "../",
+			target:   "/index.html",
+			internal: true,
+		},
+		{
+			name:     "current directory",
+			page:     "/tags/go.html",
+			link:     ".",
+			target:   "/tags/index.html",
+			internal: true,
+		},
+		{
+			name:     "fragment only",
+			page:     "/blog/post.html",
+			link:     "#setup",
+			target:   "/blog/post.html",
+			fragment: "setup",
+			internal: true,
+		},
+		{
+			name:     "query",
+			page:     "/blog/post.html",
+			link:     "/archive.html?year=2022",
+			target:   "/archive.html",
+			internal: true,
+		},
+		{
+			name:     "own host",
+			page:     "/index.html",
+			link:     "https://example.com/blog/post.html#intro",
+			target:   "/blog/post.html",
+			fragment: "intro",
+			internal: true,
+		},
+		{
+			name:     "own host root",
+			page:     "/about.html",
+			link:     "https://example.com/",
+			target:   "/index.html",
+			internal: true,
+		},
+		{
+			name:     "own host without a scheme",
+			page:     "/index.html",
+			link:     "//example.com/about.html",
+			target:   "/about.html",
+			internal: true,
+		},
+		{
+			name:     "own host with a base path",
+			baseURL:  "https://example.com/site",
+			page:     "/index.html",
+			link:     "https://example.com/site/blog/post.html",
+			target:   "/blog/post.html",
+			internal: true,
+		},
+		{
+			name:     "base path itself",
+			baseURL:  "https://example.com/site",
+			page:     "/blog/post.html",
+			link:     "https://example.com/site",
+			target:   "/index.html",
+			internal: true,
+		},
+		{
+			name:    "own host outside the base path",
+			baseURL: "https://example.com/site",
+			page:    "/index.html",
+			link:    "https://example.com/sitemap.html",
+		},
+		{
+			name: "other host",
+			page: "/index.html",
+			link: "https://go.dev/doc/",
+		},
+		{
+			name: "javascript",
+			page: "/index.html",
+			link: "javascript:void(0)",
+		},
+		{
+			name: "mailto",
+			page: "/about.html",
+			link: "mailto:jane@example.com",
+		},
+		{
+			name: "own host, other scheme",
+			page: "/index.html",
+			link: "ftp://example.com/about.html",
+		},
+		{
+			name: "invalid",
+			page: "/index.html",
+			link: "http://[::1",
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			baseURL := test.baseURL
+			if baseURL == "" {
+				baseURL = "https://example.com"
+			}
+			b := &builder{site: Config{BaseURL: baseURL}}
+
+			target, fragment, internal := b.resolveLink(test.page, test.link)
+			if internal != test.internal {
+				t.Fatalf("got internal %v, want %v", internal, test.internal)
+			}
+			if target != test.target || fragment != test.fragment {
+				t.Errorf("got %q#%q, want %q#%q", target, fragment, test.target, test.fragment)
+			}
+		})
+	}
+}
+
+func TestCheckLinks(t *testing.T) {
+	dir := t.TempDir()
+	files := map[string]string{
+		"index.html":      `<a href="/blog/post.html#intro">ok</a><a href="/blog/">dir</a><a href="mailto:jane@example.com">mail</a>`,
+		"blog/index.html": `<a href="post.html">ok</a>`,
+		"blog/post.html":  `<h2 id="intro">Intro</h2><a href="#intro">ok</a><a href="#outro">bad</a><a href="../missing.html">bad</a><img src="/images/a.png">`,
+		"images/a.png":    "png",
+	}
+	for name, content := range files {
+		name = filepath.Join(dir, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(name), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(name, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	b := &builder{
+		site:        Config{BaseURL: "https://example.com"},
+		pageSources: map[string]string{"/blog/post.html": "content/post.md"},
+	}
+	var probs problems
+	b.checkLinks(dir, &probs)
+
+	want := []string{
+		`content/post.md: /blog/post.html: broken link "#outro", missing anchor #outro in /blog/post.html`,
+		`content/post.md: /blog/post.html: broken link "../missing.html", missing /missing.html`,
+	}
+	var got []string
+	for _, prob := range probs {
+		got = append(got, prob.Error())
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got problems\n%s\nwant\n%s", strings.Join(got, "\n"), strings.Join(want, "\n"))
+	}
+}
@@ -0,0 +1,101 @@
+package gen
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// manifestFile is kept in the output folder, hidden so it is not served.
+const manifestFile = ".manifest.json"
+
+// manifest records the hash of every input of a build and, for each
+// output file, the key of the inputs it was rendered from. Outputs whose
+// key did not change since the last build are reused instead of being
+// rendered again.
+type manifest struct {
+	Inputs  map[string]string `json:"inputs"`
+	Outputs map[string]string `json:"outputs"`
+}
+
+func newManifest() manifest {
+	return manifest{Inputs: map[string]string{}, Outputs: map[string]string{}}
+}
+
+// loadManifest reads the manifest from dir. Any problem reading it just
+// means everything gets rendered.
+func loadManifest(dir string) manifest {
+	b, err := os.ReadFile(filepath.Join(dir, manifestFile))
+	if err != nil {
+		return newManifest()
+	}
+
+	m := newManifest()
+	if err := json.Unmarshal(b, &m); err != nil || m.Inputs == nil || m.Outputs == nil {
+		return newManifest()
+	}
+	return m
+}
+
+func (m manifest) write(dir string) error {
+	content, err := json.MarshalIndent(m, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(filepath.Join(dir, manifestFile), content, 0644)
+}
+
+// input records an input file and returns its hash.
+func (m manifest) input(path string, content []byte) string {
+	hash := hashOf(string(content))
+	m.Inputs[filepath.ToSlash(path)] = hash
+	return hash
+}
+
+// hashOf hashes the parts, each one delimited so ("ab", "c") and
+// ("a", "bc") do not collide.
+func hashOf(parts ...string) string {
+	h := sha256.New()
+	for _, part := range parts {
+		h.Write([]byte(part))
+		h.Write([]byte{0})
+	}
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// writeOutput writes the file at path, relative to out. If the last build
+// rendered it from the same key it is reused, otherwise render is called.
+func (b *builder) writeOutput(out, path, key string, render func() ([]byte, error)) error {
+	path = strings.TrimPrefix(path, "/")
+	b.thisBuild.Outputs[path] = key
+
+	if key != "" && b.lastBuild.Outputs[path] == key && b.reuse(out, path) {
+		return nil
+	}
+
+	content, err := render()
+	if err != nil {
+		delete(b.thisBuild.Outputs, path)
+		return err
+	}
+	return os.WriteFile(filepath.Join(out, path), content, 0644)
+}
+
+// reuse brings the published version of path into out.
+func (b *builder) reuse(out, path string) bool {
+	src, dst := filepath.Join(b.site.OutputDir, path), filepath.Join(out, path)
+
+	// The published site is replaced right after, a hard link is enough.
+	if err := os.Link(src, dst); err == nil {
+		return true
+	}
+
+	content, err := os.ReadFile(src)
+	if err != nil {
+		return false
+	}
+	return os.WriteFile(dst, content, 0644) == nil
+}
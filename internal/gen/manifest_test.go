@@ -0,0 +1,113 @@
+package gen
+
+import (
+	"io/fs"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+)
+
+func TestIncrementalBuild(t *testing.T) {
+	dir := t.TempDir()
+	content := filepath.Join(dir, "content")
+	cfg := Config{
+		Title:        "Test site",
+		Author:       "Jane Doe",
+		BaseURL:      "https://example.com",
+		Image:        "/images/cesar_gopher.png",
+		SourceDir:    content,
+		OutputDir:    filepath.Join(dir, "public"),
+		StaticDir:    filepath.Join("..", "..", "static"),
+		PostsPerPage: 5,
+	}
+
+	write := func(name, text string) {
+		t.Helper()
+		if err := os.MkdirAll(filepath.Dir(name), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(name, []byte(text), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	post := func(name, date string) {
+		write(filepath.Join(content, name+".md"), "---\ntitle: Post "+name+"\ndate: "+date+"\ntags: [go]\n---\n\nAbout "+name+".\n")
+	}
+	write(filepath.Join(content, "about.md"), "# About\n\nJane writes about Go.\n")
+	post("a", "2022-01-01")
+	post("b", "2022-02-01")
+	post("c", "2022-03-01")
+
+	// build returns the rendered files of the published site, the static
+	// assets are copied on every run.
+	build := func() map[string]fs.FileInfo {
+		t.Helper()
+		if err := Build(cfg); err != nil {
+			t.Fatal(err)
+		}
+		files := map[string]fs.FileInfo{}
+		for name := range loadManifest(cfg.OutputDir).Outputs {
+			info, err := os.Stat(filepath.Join(cfg.OutputDir, filepath.FromSlash(name)))
+			if err != nil {
+				t.Fatal(err)
+			}
+			files[name] = info
+		}
+		return files
+	}
+
+	// rendered lists the files of the published site written again, not
+	// reused, since the previous build.
+	rendered := func(before, after map[string]fs.FileInfo) []string {
+		var names []string
+		for name, info := range after {
+			if prev, ok := before[name]; !ok || !os.SameFile(prev, info) {
+				names = append(names, name)
+			}
+		}
+		slices.Sort(names)
+		return names
+	}
+
+	listings := []string{"archive.html", "index.html", rssFile, atomFile, sitemapFile, "tags/go.html", "tags/index.html"}
+
+	first := build()
+
+	second := build()
+	if got := rendered(first, second); len(got) > 0 {
+		t.Errorf("unchanged build rendered %q, want everything reused", got)
+	}
+
+	// A post between b and c changes their prev and next links.
+	post("b2", "2022-02-15")
+	third := build()
+	got := rendered(second, third)
+	for _, name := range append([]string{"blog/b2.html", "blog/b.html", "blog/c.html"}, listings...) {
+		if !slices.Contains(got, name) {
+			t.Errorf("adding a post did not render %s", name)
+		}
+	}
+	for _, name := range []string{"blog/a.html", "about.html", robotsFile} {
+		if slices.Contains(got, name) {
+			t.Errorf("adding a post rendered %s again", name)
+		}
+	}
+
+	if err := os.Remove(filepath.Join(content, "b2.md")); err != nil {
+		t.Fatal(err)
+	}
+	fourth := build()
+	if _, ok := fourth["blog/b2.html"]; ok {
+		t.Error("removed post is still published")
+	}
+	got = rendered(third, fourth)
+	for _, name := range append([]string{"blog/b.html", "blog/c.html"}, listings...) {
+		if !slices.Contains(got, name) {
+			t.Errorf("removing a post did not render %s", name)
+		}
+	}
+	if slices.Contains(got, "blog/a.html") {
+		t.Error("removing a post rendered blog/a.html again")
+	}
+}
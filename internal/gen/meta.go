@@ -0,0 +1,71 @@
+package gen
+
+import (
+	"encoding/json"
+	"strings"
+	"time"
+)
+
+// absURL makes a site path absolute, leaving full URLs untouched. Social
+// media previews ignore relative addresses.
+func (b *builder) absURL(path string) string {
+	if strings.HasPrefix(path, "http://") || strings.HasPrefix(path, "https://") {
+		return path
+	}
+	return b.site.BaseURL + "/" + strings.TrimPrefix(path, "/")
+}
+
+// description is what previews show under the page title.
+func (b *builder) description(p page) string {
+	if p.Description != "" {
+		return p.Description
+	}
+	return b.site.Description
+}
+
+// jsonLD describes a post as a schema.org BlogPosting, see
+// https://schema.org/BlogPosting. Other pages have none.
+func (b *builder) jsonLD(p page) (string, error) {
+	if !p.Article {
+		return "", nil
+	}
+
+	type person struct {
+		Type string `json:"@type"`
+		Name string `json:"name"`
+		URL  string `json:"url"`
+	}
+
+	author := person{Type: "Person", Name: b.site.Author, URL: b.site.BaseURL + "/about.html"}
+	posting := struct {
+		Context          string `json:"@context"`
+		Type             string `json:"@type"`
+		Headline         string `json:"headline"`
+		Description      string `json:"description,omitempty"`
+		Image            string `json:"image"`
+		URL              string `json:"url"`
+		MainEntityOfPage string `json:"mainEntityOfPage"`
+		DatePublished    string `json:"datePublished"`
+		Keywords         string `json:"keywords,omitempty"`
+		WordCount        int    `json:"wordCount,omitempty"`
+		Author           person `json:"author"`
+		Publisher        person `json:"publisher"`
+	}{
+		Context:          "https://schema.org",
+		Type:             "BlogPosting",
+		Headline:         p.Title,
+		Description:      p.Description,
+		Image:            b.absURL(b.assetURL(p.Image)),
+		URL:              b.url(p),
+		MainEntityOfPage: b.url(p),
+		DatePublished:    p.Date.Format(time.RFC3339),
+		Keywords:         strings.Join(p.Tags, ", "),
+		WordCount:        p.Words,
+		Author:           author,
+		Publisher:        author,
+	}
+
+	// Marshal escapes <, > and &, so it is safe inside the script tag.
+	content, err := json.Marshal(posting)
+	return string(content), err
+}
@@ -0,0 +1,164 @@
+package gen
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"slices"
+)
+
+// requiredFiles must be in every generated site, a build missing any of
+// them is not published.
+var requiredFiles = []string{"index.html", "about.html", "archive.html", rssFile, atomFile, sitemapFile, robotsFile}
+
+// PlaceholderFile is committed in the output folder of the blog, so a
+// fresh checkout has something to embed before the site is generated.
+// It is not part of the site: publish carries it over from the previous
+// output and the server does not serve it.
+const PlaceholderFile = "README"
+
+const placeholderText = "Generated by cmd/gen, run go generate ./cmd/blog to build the site.\n"
+
+// stage creates an empty directory next to the output folder, where the
+// site is rendered before being published. Being in the same parent
+// keeps it in the same file system, so publishing is a rename.
+func stage(output string) (string, error) {
+	parent := filepath.Dir(filepath.Clean(output))
+	err := os.MkdirAll(parent, 0755)
+	if err != nil {
+		return "", err
+	}
+
+	staging, err := os.MkdirTemp(parent, ".gen-")
+	if err != nil {
+		return "", err
+	}
+
+	err = os.Chmod(staging, 0755)
+	if err != nil {
+		return "", errors.Join(err, os.RemoveAll(staging))
+	}
+
+	return staging, nil
+}
+
+// verify checks the staged site before it replaces the published one.
+func (b *builder) verify(staging string, probs *problems) {
+	for _, name := range requiredFiles {
+		info, err := os.Stat(filepath.Join(staging, name))
+		switch {
+		case err != nil:
+			probs.add("", fmt.Errorf("staged site is missing %s", name))
+		case info.Size() == 0:
+			probs.add("", fmt.Errorf("staged site has an empty %s", name))
+		}
+	}
+
+	b.checkLinks(staging, probs)
+}
+
+// publish swaps the output folder with the staged one. Anything left in
+// the previous output, like pages of removed posts, goes away with it,
+// but the placeholder, unless the site has a file by that name.
+//
+// On Linux the swap is atomic, the output folder always holds a whole
+// site. Elsewhere, or when there is no output folder yet, it is done by
+// replace.
+func publish(staging, output string) error {
+	err := checkOutput(output)
+	if err != nil {
+		return err
+	}
+
+	err = exchange(staging, output)
+	if err != nil {
+		// Nothing moved, renaming is still an option.
+		return replace(staging, output)
+	}
+
+	// The previous site is in staging now.
+	err = keepPlaceholder(staging, output)
+	if err != nil {
+		return err
+	}
+
+	return os.RemoveAll(staging)
+}
+
+// checkOutput refuses output folders that might not be an earlier build,
+// publishing deletes whatever they hold. Missing and empty folders are
+// fine, as are the ones holding only the placeholder or a manifest.
+func checkOutput(output string) error {
+	entries, err := os.ReadDir(output)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	if slices.ContainsFunc(entries, func(e fs.DirEntry) bool { return e.Name() == manifestFile }) {
+		return nil
+	}
+	if len(entries) == 0 {
+		return nil
+	}
+	if len(entries) == 1 && entries[0].Name() == PlaceholderFile {
+		b, err := os.ReadFile(filepath.Join(output, PlaceholderFile))
+		if err == nil && string(b) == placeholderText {
+			return nil
+		}
+	}
+	return fmt.Errorf("%s is not empty and has no %s, refusing to replace it", output, manifestFile)
+}
+
+// replace puts the staged site in place of the output folder with two
+// renames, it is not atomic: in between there is no output folder, the
+// dev server answers 404s for that moment, and when the second rename
+// fails, putting the previous site back can fail too.
+func replace(staging, output string) error {
+	old := filepath.Join(filepath.Dir(filepath.Clean(output)), ".gen-old-"+filepath.Base(output))
+	err := os.RemoveAll(old)
+	if err != nil {
+		return err
+	}
+
+	err = os.Rename(output, old)
+	if err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return err
+	}
+
+	err = os.Rename(staging, output)
+	if err != nil {
+		// Put the previous site back in place.
+		return errors.Join(err, os.Rename(old, output))
+	}
+
+	err = keepPlaceholder(old, output)
+	if err != nil {
+		return err
+	}
+
+	return os.RemoveAll(old)
+}
+
+// keepPlaceholder moves the placeholder from the previous output to the
+// new one, when the previous output had it and the site did not replace
+// it with a file of its own.
+func keepPlaceholder(old, output string) error {
+	b, err := os.ReadFile(filepath.Join(old, PlaceholderFile))
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil
+	}
+	if err != nil || string(b) != placeholderText {
+		return err
+	}
+
+	_, err = os.Lstat(filepath.Join(output, PlaceholderFile))
+	if !errors.Is(err, fs.ErrNotExist) {
+		return err
+	}
+	return os.WriteFile(filepath.Join(output, PlaceholderFile), b, 0644)
+}
@@ -0,0 +1,100 @@
+package gen
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestPublish(t *testing.T) {
+	dir := t.TempDir()
+	output := filepath.Join(dir, "public")
+	write := func(name, content string) {
+		t.Helper()
+		if err := os.MkdirAll(filepath.Dir(name), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(name, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	write(filepath.Join(output, PlaceholderFile), placeholderText)
+
+	// Publishing twice goes through both the first publication, with the
+	// placeholder only, and the replacement of an earlier build.
+	for _, index := range []string{"first", "second"} {
+		staging, err := stage(output)
+		if err != nil {
+			t.Fatal(err)
+		}
+		write(filepath.Join(staging, "index.html"), index)
+		write(filepath.Join(staging, manifestFile), "{}")
+		if index == "first" {
+			write(filepath.Join(staging, "removed.html"), "gone")
+		}
+
+		if err := publish(staging, output); err != nil {
+			t.Fatal(err)
+		}
+
+		got, err := os.ReadFile(filepath.Join(output, "index.html"))
+		if err != nil || string(got) != index {
+			t.Errorf("index.html = %q, %v, want %q", got, err, index)
+		}
+		got, err = os.ReadFile(filepath.Join(output, PlaceholderFile))
+		if err != nil || string(got) != placeholderText {
+			t.Errorf("placeholder = %q, %v, want it kept", got, err)
+		}
+		if _, err := os.Stat(filepath.Join(output, "removed.html")); (err == nil) != (index == "first") {
+			t.Errorf("removed.html published: %v, want it only in the first build", err == nil)
+		}
+		if _, err := os.Stat(staging); err == nil {
+			t.Error("staging is left behind")
+		}
+	}
+
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 1 {
+		t.Errorf("%d entries next to the output, want only the output", len(entries))
+	}
+}
+
+func TestPublishRefusesOtherFolders(t *testing.T) {
+	tests := map[string]map[string]string{
+		"source code":          {"main.go": "package main\n"},
+		"other readme":         {PlaceholderFile: "My project.\n"},
+		"placeholder and more": {PlaceholderFile: placeholderText, "notes.txt": "keep me"},
+	}
+	for name, files := range tests {
+		t.Run(name, func(t *testing.T) {
+			output := filepath.Join(t.TempDir(), "victim")
+			if err := os.Mkdir(output, 0755); err != nil {
+				t.Fatal(err)
+			}
+			for file, content := range files {
+				if err := os.WriteFile(filepath.Join(output, file), []byte(content), 0644); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			staging, err := stage(output)
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer os.RemoveAll(staging)
+
+			if err := publish(staging, output); err == nil {
+				t.Fatal("published over a folder that is not a build")
+			}
+			for file, content := range files {
+				got, err := os.ReadFile(filepath.Join(output, file))
+				if err != nil || string(got) != content {
+					t.Errorf("%s = %q, %v, want it untouched", file, got, err)
+				}
+			}
+		})
+	}
+}
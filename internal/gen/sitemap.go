@@ -0,0 +1,42 @@
+package gen
+
+import (
+	"encoding/xml"
+	"fmt"
+)
+
+const (
+	sitemapFile = "sitemap.xml"
+	robotsFile  = "robots.txt"
+)
+
+// Sitemap protocol 0.9, https://www.sitemaps.org/protocol.html.
+type urlset struct {
+	XMLName xml.Name     `xml:"http://www.sitemaps.org/schemas/sitemap/0.9 urlset"`
+	URLs    []sitemapURL `xml:"url"`
+}
+
+type sitemapURL struct {
+	Loc     string `xml:"loc"`
+	LastMod string `xml:"lastmod,omitempty"`
+}
+
+// sitemap lists the pages with their last modification, taken from the
+// page date.
+func (b *builder) sitemap(pages []page) ([]byte, error) {
+	var set urlset
+	for _, p := range pages {
+		u := sitemapURL{Loc: b.url(p)}
+		if !p.Date.IsZero() {
+			u.LastMod = p.Date.Format("2006-01-02")
+		}
+		set.URLs = append(set.URLs, u)
+	}
+
+	return marshalXML(set)
+}
+
+// robots allows crawling everything and points crawlers to the sitemap.
+func (b *builder) robots() []byte {
+	return fmt.Appendf(nil, "User-agent: *\nAllow: /\n\nSitemap: %s/%s\n", b.site.BaseURL, sitemapFile)
+}
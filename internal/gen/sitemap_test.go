@@ -0,0 +1,31 @@
+package gen
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestSitemap(t *testing.T) {
+	b := &builder{site: Config{BaseURL: "https://example.com"}}
+	pages := []page{
+		{Path: "/index.html", Date: time.Date(2024, 5, 20, 0, 0, 0, 0, time.UTC)},
+		// Pages without metadata, like an about page without front matter.
+		{Path: "/about.html"},
+	}
+
+	got, err := b.sitemap(pages)
+	if err != nil {
+		t.Fatal(err)
+	}
+	checkWellFormed(t, got)
+
+	for _, want := range []string{
+		"<url>\n    <loc>https://example.com/</loc>\n    <lastmod>2024-05-20</lastmod>\n  </url>",
+		"<url>\n    <loc>https://example.com/about.html</loc>\n  </url>",
+	} {
+		if !strings.Contains(string(got), want) {
+			t.Errorf("sitemap has no\n%s\ngot:\n%s", want, got)
+		}
+	}
+}
@@ -0,0 +1,124 @@
+package gen
+
+import (
+	"bytes"
+	"cmp"
+	"maps"
+	"slices"
+	"strings"
+)
+
+// tagsDir holds one listing page per tag, plus the tag index.
+const tagsDir = "tags/"
+
+// tagLink is a tag as rendered in the pages.
+type tagLink struct {
+	Name  string
+	Href  string
+	Count int
+}
+
+// tagSlug is the file name friendly version of a tag, only lower case
+// letters, digits and dashes: "Go Modules" becomes "go-modules" and
+// "c/c++" becomes "c-c". It is empty for tags with neither.
+func tagSlug(tag string) string {
+	var b strings.Builder
+	dash := false
+	for _, r := range strings.ToLower(tag) {
+		if ('a' <= r && r <= 'z') || ('0' <= r && r <= '9') {
+			if dash && b.Len() > 0 {
+				b.WriteByte('-')
+			}
+			b.WriteRune(r)
+			dash = false
+			continue
+		}
+		dash = true
+	}
+	return b.String()
+}
+
+func tagPath(tag string) string {
+	return "/" + tagsDir + tagSlug(tag) + ".html"
+}
+
+// tagLinks links the tags of a page to their listing pages.
+func (p page) tagLinks() []tagLink {
+	links := make([]tagLink, 0, len(p.Tags))
+	for _, tag := range p.Tags {
+		links = append(links, tagLink{Name: tag, Href: tagPath(tag)})
+	}
+	return links
+}
+
+// taxonomy builds one listing page per tag, newest posts first, and the
+// tag index with the post count of each tag. Tags are grouped by slug,
+// so "Go" and "go" end up in the same page.
+func (b *builder) taxonomy(pages []page) ([]page, error) {
+	type item struct {
+		Date  string
+		Title string
+		Dest  string
+	}
+	type tag struct {
+		Name  string
+		Items []item
+	}
+
+	bySlug := map[string]*tag{}
+	for i := len(pages) - 1; i >= 0; i-- {
+		for _, name := range pages[i].Tags {
+			slug := tagSlug(name)
+			t, ok := bySlug[slug]
+			if !ok {
+				t = &tag{Name: name}
+				bySlug[slug] = t
+			}
+			t.Items = append(t.Items, item{
+				Date:  pages[i].Date.Format("2006/01/02"),
+				Title: pages[i].Title,
+				Dest:  pages[i].Path,
+			})
+		}
+	}
+
+	var tagPages []page
+	var links []tagLink
+	for _, slug := range slices.Sorted(maps.Keys(bySlug)) {
+		t := bySlug[slug]
+
+		var content bytes.Buffer
+		err := b.tagTemplate.Execute(&content, t)
+		if err != nil {
+			return nil, err
+		}
+
+		tagPages = append(tagPages, page{
+			Title:   "Tagged " + t.Name,
+			Path:    tagPath(t.Name),
+			Date:    lastUpdate(pages),
+			Image:   b.site.Image,
+			Content: content.Bytes(),
+		})
+		links = append(links, tagLink{Name: t.Name, Href: tagPath(t.Name), Count: len(t.Items)})
+	}
+
+	// Most used tags first.
+	slices.SortStableFunc(links, func(a, b tagLink) int {
+		return cmp.Compare(b.Count, a.Count)
+	})
+
+	var content bytes.Buffer
+	err := b.tagsTemplate.Execute(&content, struct{ Tags []tagLink }{Tags: links})
+	if err != nil {
+		return nil, err
+	}
+
+	return append(tagPages, page{
+		Title:   "Tags",
+		Path:    "/" + tagsDir + "index.html",
+		Date:    lastUpdate(pages),
+		Image:   b.site.Image,
+		Content: content.Bytes(),
+	}), nil
+}
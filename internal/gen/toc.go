@@ -0,0 +1,84 @@
+package gen
+
+import (
+	"io"
+	"strings"
+
+	"github.com/gomarkdown/markdown/ast"
+	"github.com/gomarkdown/markdown/html"
+)
+
+// tocMinEntries is how many sections a post needs for a table of contents
+// to be worth it.
+const tocMinEntries = 3
+
+// Only sections and their subsections are listed, the smaller headings
+// are used for subtitles and notes.
+const (
+	tocLevel    = 2
+	tocMaxLevel = 3
+)
+
+type tocEntry struct {
+	Title    string
+	ID       string
+	Children []tocEntry
+}
+
+// tableOfContents lists the sections of doc, nesting subsections under
+// the section they are in. Short documents get none.
+func tableOfContents(doc ast.Node) []tocEntry {
+	var toc []tocEntry
+	count := 0
+	ast.WalkFunc(doc, func(node ast.Node, entering bool) ast.WalkStatus {
+		heading, ok := node.(*ast.Heading)
+		if !ok || !entering {
+			return ast.GoToNext
+		}
+		if heading.HeadingID == "" || heading.Level < tocLevel || heading.Level > tocMaxLevel {
+			return ast.SkipChildren
+		}
+
+		entry := tocEntry{Title: plainText(heading), ID: heading.HeadingID}
+		// Subsections before any section are listed as sections.
+		if heading.Level > tocLevel && len(toc) > 0 {
+			last := &toc[len(toc)-1]
+			last.Children = append(last.Children, entry)
+		} else {
+			toc = append(toc, entry)
+		}
+		count++
+		return ast.SkipChildren
+	})
+
+	if count < tocMinEntries {
+		return nil
+	}
+	return toc
+}
+
+// plainText is the text of node without any markup.
+func plainText(node ast.Node) string {
+	var b strings.Builder
+	ast.WalkFunc(node, func(node ast.Node, entering bool) ast.WalkStatus {
+		if leaf := node.AsLeaf(); leaf != nil && entering {
+			b.Write(leaf.Literal)
+		}
+		return ast.GoToNext
+	})
+	return strings.Join(strings.Fields(b.String()), " ")
+}
+
+// renderHeading adds a permalink to the end of the headings with an id,
+// leaving the rest to the default renderer.
+func renderHeading(w io.Writer, node ast.Node, entering bool) (ast.WalkStatus, bool) {
+	heading, ok := node.(*ast.Heading)
+	if !ok || entering || heading.HeadingID == "" || heading.Level < tocLevel {
+		return ast.GoToNext, false
+	}
+
+	io.WriteString(w, ` <a class="permalink" href="#`)
+	html.EscapeHTML(w, []byte(heading.HeadingID))
+	io.WriteString(w, `" aria-label="Permalink">#</a>`)
+	return ast.GoToNext, false
+}
@@ -0,0 +1,63 @@
+package gen
+
+import (
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/gomarkdown/markdown/ast"
+)
+
+// wordsPerMinute is an average adult reading speed, a bit on the slow
+// side as posts are technical.
+const wordsPerMinute = 200
+
+// wordCount counts the words of the prose in doc, code blocks are not
+// read the same way so they are left out.
+func wordCount(doc ast.Node) int {
+	var b strings.Builder
+	ast.WalkFunc(doc, func(node ast.Node, entering bool) ast.WalkStatus {
+		switch node := node.(type) {
+		case *ast.CodeBlock:
+			return ast.SkipChildren
+		case *ast.Text, *ast.Code:
+			if entering {
+				b.Write(node.AsLeaf().Literal)
+			}
+		case *ast.Paragraph, *ast.Heading, *ast.ListItem, *ast.TableCell, *ast.Softbreak, *ast.Hardbreak:
+			// Words never span blocks.
+			b.WriteByte(' ')
+		}
+		return ast.GoToNext
+	})
+
+	count := 0
+	for _, field := range strings.Fields(b.String()) {
+		// Punctuation alone, like dashes, is not a word.
+		if strings.IndexFunc(field, func(r rune) bool { return unicode.IsLetter(r) || unicode.IsDigit(r) }) >= 0 {
+			count++
+		}
+	}
+	return count
+}
+
+// readingTime is how many minutes reading words takes, at least one.
+func readingTime(words int) int {
+	return max(1, (words+wordsPerMinute-1)/wordsPerMinute)
+}
+
+// longDate formats t the way posts always had their dates written,
+// like "December 20th, 2021".
+func longDate(t time.Time) string {
+	suffix := "th"
+	switch day := t.Day(); {
+	case day >= 11 && day <= 13:
+	case day%10 == 1:
+		suffix = "st"
+	case day%10 == 2:
+		suffix = "nd"
+	case day%10 == 3:
+		suffix = "rd"
+	}
+	return t.Format("January 2") + suffix + t.Format(", 2006")
+}